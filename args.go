@@ -4,9 +4,31 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"regexp"
+)
+
+// Modes are selected with an optional subcommand
+// before the options (e.g. `WazuhTest coverage -d ./tests host`)
+const (
+	RunMode      = "run"
+	CoverageMode = "coverage"
+	AnalyzeMode  = "analyze"
+)
+
+// Where logs are processed
+const (
+	BackendManager = "manager"
+	BackendLocal   = "local"
+)
+
+// How test results are written to stdout
+const (
+	FormatConsole = "console"
+	FormatTAP     = "tap"
 )
 
 type Arguments struct {
+	Mode       string
 	Host       string
 	TestsDir   string
 	User       string
@@ -16,11 +38,65 @@ type Arguments struct {
 	Verbosity  int
 	TlsLogPath string
 	CliMode    bool
+	Format     string
+
+	// Only run the tests whose ID matches
+	RunFilter *regexp.Regexp
+
+	// Number of slowest tests to report
+	Slowest int
+
+	// Watch mode
+	Watch         bool
+	WatchInterval int
+
+	// Comparing managers. Set when more than one host
+	// or a managers file is given.
+	Managers []managerProfile
+	DiffOnly bool
+
+	// Local rule engine
+	Backend      string
+	LocalRuleset []string
+
+	// Ruleset deployment
+	RulesetDir   string
+	RulesetApply string
+	RulesetWait  int
+
+	// Reports
+	ComplianceReport string
+	ReportHTML       string
+	ReportMarkdown   string
+
+	// Baselines
+	SaveBaseline    string
+	CompareBaseline string
+	FailOnDrift     bool
+
+	// Coverage mode
+	RuleFilename    string
+	RuleDirname     string
+	MinRuleCoverage float64
+	DecoderFilename string
+	DecoderDirname  string
+
+	// Analyze mode
+	AnalyzePaths []string
+	RefPaths     []string
 }
 
 func parseArguments() Arguments {
 	var args Arguments
 
+	// Check for a subcommand
+	cmdArgs := os.Args[1:]
+	args.Mode = RunMode
+	if len(cmdArgs) > 0 && (cmdArgs[0] == CoverageMode || cmdArgs[0] == AnalyzeMode) {
+		args.Mode = cmdArgs[0]
+		cmdArgs = cmdArgs[1:]
+	}
+
 	flag.StringVar(&args.TestsDir, "d", "./tests", "The directory containing the test groups. Defaults to './tests'.")
 	flag.StringVar(&args.User, "u", "wazuh", "The username for the Wazuh API. Defaults to 'wazuh'.")
 	flag.StringVar(&args.Password, "p", "wazuh", "The password for the Wazuh API. Defaults to 'wazuh'.")
@@ -28,6 +104,40 @@ func parseArguments() Arguments {
 	flag.IntVar(&args.Timeout, "o", 5, "The timeout for API requests. Defaults to 5 seconds.")
 	flag.StringVar(&args.TlsLogPath, "tls-log", "", "Enable and log the TLS key to the path specified.")
 	flag.BoolVar(&args.CliMode, "c", false, "Enable cli mode for use in pipelines and automations. Defaults to false.")
+	flag.StringVar(&args.Format, "format", FormatConsole, "How results are written to stdout: 'console' or 'tap' (Test Anything Protocol). Defaults to 'console'.")
+	var runFilter string
+	flag.StringVar(&runFilter, "run", "", "Only run the tests whose ID matches this regular expression (e.g. 'ubuntu/test_ssh.json#2').")
+	flag.IntVar(&args.Slowest, "slowest", 0, "Report the N tests that took the longest to get a response. Defaults to 0 (off).")
+	flag.BoolVar(&args.Watch, "watch", false, "Keep running and rerun the tests whose definition or log files change.")
+	flag.IntVar(&args.WatchInterval, "watch-interval", 1, "Seconds between checks for changed files in watch mode. Defaults to 1 second.")
+	var managersFile string
+	flag.StringVar(&managersFile, "managers", "", "JSON file listing the managers to compare (Name, Host, User, Password). Can be used instead of several host arguments.")
+	flag.BoolVar(&args.DiffOnly, "diff-only", false, "Only show the tests whose results differ between managers.")
+	flag.StringVar(&args.Backend, "backend", BackendManager, "Where logs are processed: 'manager' or 'local' (experimental offline rule engine). Defaults to 'manager'.")
+	var localRuleset string
+	flag.StringVar(&localRuleset, "local-ruleset", "", "Comma separated decoder and rule files or directories loaded by the local backend, in load order (e.g. the default ruleset followed by custom rules).")
+	flag.StringVar(&args.RulesetDir, "ruleset", "", "Deploy the rules/, decoders/ and lists/ in this directory to the manager before testing. The original files are restored afterwards.")
+	flag.StringVar(&args.RulesetApply, "ruleset-apply", RulesetApplyRestart, "How the manager loads a deployed ruleset: 'restart' or 'reload'. Defaults to 'restart'.")
+	flag.IntVar(&args.RulesetWait, "ruleset-wait", 120, "Seconds to wait for analysisd after loading a ruleset. Defaults to 120 seconds.")
+	flag.StringVar(&args.SaveBaseline, "save-baseline", "", "Save the rule and decoder output of every test to the path specified to compare later runs against.")
+	flag.StringVar(&args.CompareBaseline, "compare-baseline", "", "Report the tests whose outcome or returned rule and decoder output changed since the baseline at the path specified.")
+	flag.BoolVar(&args.FailOnDrift, "fail-on-drift", false, "Exit with an error if any test changed since the baseline given with -compare-baseline.")
+	flag.StringVar(&args.ComplianceReport, "compliance-report", "", "Write a MITRE ATT&CK and compliance coverage report to the path specified. The format (.md, .csv or .json) is taken from the file extension.")
+	flag.StringVar(&args.ReportHTML, "report-html", "", "Write a self-contained HTML report of every test result to the path specified.")
+	flag.StringVar(&args.ReportMarkdown, "report-md", "", "Write a Markdown summary of the test results sized for a pull request comment to the path specified.")
+
+	if args.Mode == CoverageMode {
+		flag.StringVar(&args.RuleFilename, "rule-file", "", "Only measure coverage of rules in this file (e.g. 'local_rules.xml').")
+		flag.StringVar(&args.RuleDirname, "rule-dir", "", "Only measure coverage of rules in this directory relative to the Wazuh install (e.g. 'etc/rules').")
+		flag.Float64Var(&args.MinRuleCoverage, "min", 0, "Fail the run if less than this percentage of rules are covered. Defaults to 0.")
+		flag.StringVar(&args.DecoderFilename, "decoder-file", "", "Only measure coverage of decoders in this file (e.g. 'local_decoder.xml').")
+		flag.StringVar(&args.DecoderDirname, "decoder-dir", "", "Only measure coverage of decoders in this directory relative to the Wazuh install (e.g. 'etc/decoders').")
+	}
+
+	var refPaths string
+	if args.Mode == AnalyzeMode {
+		flag.StringVar(&refPaths, "ref", "", "Comma separated rule and decoder files or directories used to resolve references (e.g. the default Wazuh ruleset). Issues in these files are not reported.")
+	}
 
 	// Custom parsing for verbosity
 	var vFlag, vvFlag bool
@@ -35,10 +145,19 @@ func parseArguments() Arguments {
 	flag.BoolVar(&vvFlag, "vv", false, "Enable verbosity level 2.")
 
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [options] host\n", os.Args[0])
+		if args.Mode == RunMode {
+			fmt.Fprintf(os.Stderr, "Usage: %s [options] host\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "       %s -backend local -local-ruleset path [options]\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "       %s coverage [options] host\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "       %s analyze [options] path...\n", os.Args[0])
+		} else if args.Mode == AnalyzeMode {
+			fmt.Fprintf(os.Stderr, "Usage: %s analyze [options] path...\n", os.Args[0])
+		} else {
+			fmt.Fprintf(os.Stderr, "Usage: %s %s [options] host\n", os.Args[0], args.Mode)
+		}
 		flag.PrintDefaults()
 	}
-	flag.Parse()
+	flag.CommandLine.Parse(cmdArgs)
 
 	// Handle verbosity
 	if vvFlag {
@@ -49,6 +168,134 @@ func parseArguments() Arguments {
 		args.Verbosity = 0
 	}
 
+	if len(runFilter) > 0 {
+		filter, err := regexp.Compile(runFilter)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: invalid -run expression: "+err.Error())
+			os.Exit(1)
+		}
+		args.RunFilter = filter
+	}
+
+	if args.RunFilter != nil && len(args.SaveBaseline) > 0 {
+		fmt.Fprintln(os.Stderr, "Error: -save-baseline cannot be used with -run since the baseline would only have some of the tests.")
+		os.Exit(1)
+	}
+
+	if args.RulesetApply != RulesetApplyRestart && args.RulesetApply != RulesetApplyReload {
+		fmt.Fprintln(os.Stderr, "Error: ruleset apply must be 'restart' or 'reload'.")
+		os.Exit(1)
+	}
+
+	if args.Watch && args.Mode == CoverageMode {
+		fmt.Fprintln(os.Stderr, "Error: -watch cannot be used in coverage mode.")
+		os.Exit(1)
+	}
+
+	if args.FailOnDrift && len(args.CompareBaseline) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: -fail-on-drift requires -compare-baseline.")
+		os.Exit(1)
+	}
+
+	if args.Watch && (len(args.SaveBaseline) > 0 || len(args.CompareBaseline) > 0) {
+		fmt.Fprintln(os.Stderr, "Error: baselines cannot be used with -watch.")
+		os.Exit(1)
+	}
+
+	if args.Format != FormatConsole && args.Format != FormatTAP {
+		fmt.Fprintln(os.Stderr, "Error: format must be 'console' or 'tap'.")
+		os.Exit(1)
+	}
+
+	if args.Format == FormatTAP && args.Watch {
+		fmt.Fprintln(os.Stderr, "Error: -format tap cannot be used with -watch.")
+		os.Exit(1)
+	}
+
+	if args.Slowest < 0 {
+		fmt.Fprintln(os.Stderr, "Error: -slowest cannot be negative.")
+		os.Exit(1)
+	}
+
+	if args.WatchInterval < 1 {
+		fmt.Fprintln(os.Stderr, "Error: watch interval must be at least 1 second.")
+		os.Exit(1)
+	}
+
+	if args.MinRuleCoverage < 0 || args.MinRuleCoverage > 100 {
+		fmt.Fprintln(os.Stderr, "Error: minimum coverage must be between 0 and 100.")
+		os.Exit(1)
+	}
+
+	// Analyze mode works on local files and
+	// does not need a manager
+	if args.Mode == AnalyzeMode {
+		if len(flag.Args()) < 1 {
+			fmt.Fprintln(os.Stderr, "Error: at least one rule or decoder path is required.")
+			flag.Usage()
+			os.Exit(1)
+		}
+		args.AnalyzePaths = flag.Args()
+
+		args.RefPaths = splitList(refPaths, ",")
+
+		return args
+	}
+
+	switch args.Backend {
+	case BackendManager:
+	case BackendLocal:
+		args.LocalRuleset = splitList(localRuleset, ",")
+		if len(args.LocalRuleset) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: the local backend requires -local-ruleset.")
+			os.Exit(1)
+		}
+
+		// Everything else needs a manager
+		if args.Mode == CoverageMode || len(args.RulesetDir) > 0 {
+			fmt.Fprintln(os.Stderr, "Error: coverage mode and -ruleset require the manager backend.")
+			os.Exit(1)
+		}
+
+		return args
+	default:
+		fmt.Fprintln(os.Stderr, "Error: backend must be 'manager' or 'local'.")
+		os.Exit(1)
+	}
+
+	// Several hosts (or a managers file) run
+	// the same tests against each manager
+	var profiles []managerProfile
+	if len(managersFile) > 0 {
+		loaded, err := loadManagerProfiles(managersFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: loading managers: "+err.Error())
+			os.Exit(1)
+		}
+		profiles = append(profiles, loaded...)
+	}
+	if len(flag.Args()) > 1 || len(profiles) > 0 {
+		for _, host := range flag.Args() {
+			profiles = append(profiles, managerProfile{Host: host})
+		}
+	}
+
+	if len(profiles) > 0 {
+		if args.Mode == CoverageMode || args.Watch || len(args.RulesetDir) > 0 || len(args.ComplianceReport) > 0 || len(args.ReportHTML) > 0 || len(args.ReportMarkdown) > 0 || len(args.SaveBaseline) > 0 || len(args.CompareBaseline) > 0 || args.Format == FormatTAP {
+			fmt.Fprintln(os.Stderr, "Error: comparing managers cannot be combined with coverage mode, -watch, -ruleset, -compliance-report, -report-html, -report-md, -format tap or baselines.")
+			os.Exit(1)
+		}
+
+		managers, err := resolveManagerProfiles(profiles, args.User, args.Password)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+			os.Exit(1)
+		}
+		args.Managers = managers
+
+		return args
+	}
+
 	// Positional argument for host
 	if len(flag.Args()) < 1 {
 		fmt.Fprintln(os.Stderr, "Error: host argument is required.")
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// What the backend returned for every test of a run. Saved
+// to compare later runs against, e.g. after editing rules.
+type baseline struct {
+	Created string                  `json:"created"`
+	Tests   map[string]baselineTest `json:"tests"`
+}
+
+type baselineTest struct {
+	Description string `json:"description"`
+	Passed      bool   `json:"passed"`
+
+	// The runner status, e.g. skipped. Baselines saved
+	// before it was added only have Passed.
+	Status string `json:"status,omitempty"`
+
+	// One output per response. Sequence tests have one
+	// for every event that was sent.
+	Outputs []baselineOutput `json:"outputs"`
+}
+
+// The parts of a logtest output that are compared
+type baselineOutput struct {
+	Rule       map[string]interface{} `json:"rule,omitempty"`
+	Decoder    map[string]interface{} `json:"decoder,omitempty"`
+	Predecoder map[string]interface{} `json:"predecoder,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+// Output fields that change from run to run on their own
+var baselineVolatileFields = map[string][]string{
+	"rule":       {"firedtimes"},
+	"predecoder": {"timestamp"},
+}
+
+// Kinds of differences between a run and a baseline.
+// Changed and removed tests count as drift.
+const (
+	baselineChanged = "changed"
+	baselineAdded   = "added"
+	baselineRemoved = "removed"
+)
+
+type baselineChange struct {
+	ID          string
+	Description string
+	Kind        string
+	Details     []string
+}
+
+// Tests are keyed by their ID
+func buildBaseline(testDirs []runner.TestDir) baseline {
+	b := baseline{Created: time.Now().UTC().Format(time.RFC3339), Tests: map[string]baselineTest{}}
+
+	for _, dir := range testDirs {
+		for i, test := range dir.Tests {
+			result := dir.Results[i]
+			entry := baselineTest{Description: test.GetDisplayName(), Passed: result.Passed, Status: result.GetStatus(), Outputs: []baselineOutput{}}
+
+			// Skipped tests sent nothing
+			if !result.Skipped {
+				for _, response := range result.GetResponses() {
+					entry.Outputs = append(entry.Outputs, getBaselineOutput(response))
+				}
+			}
+			b.Tests[test.ID] = entry
+		}
+	}
+
+	return b
+}
+
+// Drops the tests that the run left out so they are not
+// reported as removed. With -run the filter decides and in
+// focus mode only the tests that ran were selected.
+func pruneBaseline(old baseline, current baseline, filter *regexp.Regexp, focused bool) {
+	for id := range old.Tests {
+		_, ran := current.Tests[id]
+		if (filter != nil && !filter.MatchString(id)) || (focused && !ran) {
+			delete(old.Tests, id)
+		}
+	}
+}
+
+// Focus mode only keeps the tests marked Only
+func isFocused(testDirs []runner.TestDir) bool {
+	for _, dir := range testDirs {
+		for _, test := range dir.Tests {
+			if test.Only {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func getBaselineOutput(response wazuh.Response) baselineOutput {
+	section := func(name string) map[string]interface{} {
+		value, ok := testdef.LookupJSONPath(response.Raw, "data.output."+name)
+		if !ok {
+			return nil
+		}
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		// Copy so the response is left as is
+		copied := map[string]interface{}{}
+		for key, val := range fields {
+			copied[key] = val
+		}
+		for _, key := range baselineVolatileFields[name] {
+			delete(copied, key)
+		}
+		return copied
+	}
+
+	return baselineOutput{
+		Rule:       section("rule"),
+		Decoder:    section("decoder"),
+		Predecoder: section("predecoder"),
+		Data:       section("data"),
+	}
+}
+
+func loadBaseline(path string) (baseline, error) {
+	var b baseline
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return b, err
+	}
+
+	if err := json.Unmarshal(data, &b); err != nil {
+		return b, fmt.Errorf("error parsing %s: %s", path, err)
+	}
+
+	return b, nil
+}
+
+func saveBaseline(path string, b baseline) error {
+	data, err := json.MarshalIndent(b, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Lists the tests whose outcome or returned values differ
+// from the baseline, sorted by ID, along with the tests
+// that were added or removed since
+func compareBaseline(old baseline, current baseline) []baselineChange {
+	var changes []baselineChange
+
+	for id, test := range current.Tests {
+		oldTest, ok := old.Tests[id]
+		if !ok {
+			changes = append(changes, baselineChange{ID: id, Description: test.Description, Kind: baselineAdded})
+			continue
+		}
+
+		details := compareBaselineTests(oldTest, test)
+		if len(details) > 0 {
+			changes = append(changes, baselineChange{ID: id, Description: test.Description, Kind: baselineChanged, Details: details})
+		}
+	}
+
+	for id, test := range old.Tests {
+		if _, ok := current.Tests[id]; !ok {
+			changes = append(changes, baselineChange{ID: id, Description: test.Description, Kind: baselineRemoved})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ID < changes[j].ID
+	})
+
+	return changes
+}
+
+func compareBaselineTests(old baselineTest, current baselineTest) []string {
+	var details []string
+
+	if old.getStatus() != current.getStatus() {
+		details = append(details, "Outcome: "+old.getStatus()+" -> "+current.getStatus())
+	}
+
+	// There is nothing to compare the outputs of a
+	// skipped test with
+	if old.getStatus() == runner.StatusSkipped || current.getStatus() == runner.StatusSkipped {
+		return details
+	}
+
+	if len(old.Outputs) != len(current.Outputs) {
+		details = append(details, fmt.Sprintf("Responses: %d -> %d", len(old.Outputs), len(current.Outputs)))
+	}
+
+	for i := 0; i < len(old.Outputs) && i < len(current.Outputs); i++ {
+		prefix := ""
+		if len(current.Outputs) > 1 {
+			prefix = "Response " + strconv.Itoa(i+1) + ": "
+		}
+
+		oldFields := old.Outputs[i].flatten()
+		currentFields := current.Outputs[i].flatten()
+
+		var paths []string
+		for path := range oldFields {
+			paths = append(paths, path)
+		}
+		for path := range currentFields {
+			if _, ok := oldFields[path]; !ok {
+				paths = append(paths, path)
+			}
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			oldVal, oldOk := oldFields[path]
+			currentVal, currentOk := currentFields[path]
+			if oldOk && currentOk && oldVal == currentVal {
+				continue
+			}
+			if !oldOk {
+				oldVal = "(missing)"
+			}
+			if !currentOk {
+				currentVal = "(missing)"
+			}
+			details = append(details, prefix+path+": "+oldVal+" -> "+currentVal)
+		}
+	}
+
+	return details
+}
+
+func (test baselineTest) getStatus() string {
+	if test.Status != "" {
+		return test.Status
+	}
+	if test.Passed {
+		return runner.StatusPassed
+	}
+	return runner.StatusFailed
+}
+
+// The output as dotted paths to formatted values. Arrays
+// are kept whole so a reordered list shows as one change.
+func (output baselineOutput) flatten() map[string]string {
+	fields := map[string]string{}
+
+	var walk func(prefix string, value interface{})
+	walk = func(prefix string, value interface{}) {
+		if obj, ok := value.(map[string]interface{}); ok {
+			for key, val := range obj {
+				walk(prefix+"."+key, val)
+			}
+			return
+		}
+		fields[prefix] = testdef.FormatJSONValue(value)
+	}
+
+	for name, section := range map[string]map[string]interface{}{
+		"rule":       output.Rule,
+		"decoder":    output.Decoder,
+		"predecoder": output.Predecoder,
+		"data":       output.Data,
+	} {
+		for key, val := range section {
+			walk(name+"."+key, val)
+		}
+	}
+
+	return fields
+}
+
+// Returns the number of changed and removed tests
+func printBaselineComparison(con console, changes []baselineChange, path string) int {
+	con.boldWhite("Baseline Comparison:")
+	con.boldWhite("====================\n")
+
+	numChanged, numAdded, numRemoved := 0, 0, 0
+	for _, change := range changes {
+		switch change.Kind {
+		case baselineChanged:
+			numChanged++
+			con.red("[CHANGED] " + change.ID + " " + change.Description)
+			for _, detail := range change.Details {
+				con.red("+ " + detail)
+			}
+		case baselineAdded:
+			numAdded++
+			con.yellow("[NEW] " + change.ID + " " + change.Description)
+		case baselineRemoved:
+			numRemoved++
+			con.red("[REMOVED] " + change.ID + " " + change.Description)
+		}
+	}
+
+	if len(changes) == 0 {
+		con.green("No changes since baseline: " + path)
+	} else {
+		con.printf("\n")
+		con.white(fmt.Sprintf("%d changed, %d new and %d removed tests since baseline: %s", numChanged, numAdded, numRemoved, path))
+	}
+	con.printf("\n")
+
+	return numChanged + numRemoved
+}
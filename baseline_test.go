@@ -0,0 +1,132 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+func Test_getBaselineOutput(t *testing.T) {
+	response := wazuh.Response{Raw: map[string]interface{}{"data": map[string]interface{}{"output": map[string]interface{}{
+		"rule":       map[string]interface{}{"id": "5710", "level": 5.0, "firedtimes": 3.0},
+		"predecoder": map[string]interface{}{"program_name": "sshd", "timestamp": "Mar  5 13:49:34"},
+		"decoder":    map[string]interface{}{"name": "sshd"},
+		"full_log":   "Mar  5 13:49:34 ip-10-0-0-10 sshd[1602]: Invalid user bob",
+	}}}}
+
+	got := getBaselineOutput(response)
+	want := baselineOutput{
+		Rule:       map[string]interface{}{"id": "5710", "level": 5.0},
+		Decoder:    map[string]interface{}{"name": "sshd"},
+		Predecoder: map[string]interface{}{"program_name": "sshd"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getBaselineOutput() = %+v, want %+v", got, want)
+	}
+
+	// The response must not be changed
+	if _, ok := testdef.LookupJSONPath(response.Raw, "data.output.rule.firedtimes"); !ok {
+		t.Errorf("getBaselineOutput() removed firedtimes from the response")
+	}
+}
+
+func Test_compareBaseline(t *testing.T) {
+	output := func(level float64, srcuser string) baselineOutput {
+		return baselineOutput{
+			Rule: map[string]interface{}{"id": "5710", "level": level},
+			Data: map[string]interface{}{"srcuser": srcuser, "win": map[string]interface{}{"system": map[string]interface{}{"eventID": 4625.0}}},
+		}
+	}
+
+	old := baseline{Tests: map[string]baselineTest{
+		"ubuntu/test_ssh.json#1": {Description: "Same", Passed: true, Outputs: []baselineOutput{output(5, "bob")}},
+		"ubuntu/test_ssh.json#2": {Description: "Drifted", Passed: true, Outputs: []baselineOutput{output(5, "bob")}},
+		"ubuntu/test_ssh.json#3": {Description: "Outcome", Passed: true, Outputs: []baselineOutput{output(5, "bob")}},
+		"ubuntu/test_ssh.json#4": {Description: "Removed", Passed: true, Outputs: []baselineOutput{output(5, "bob")}},
+		"ubuntu/test_ssh.json#5": {Description: "Skipped", Passed: true, Status: runner.StatusPassed, Outputs: []baselineOutput{output(5, "bob")}},
+		"ubuntu/test_ssh.json#6": {Description: "No status", Passed: true, Outputs: []baselineOutput{output(5, "bob")}},
+	}}
+	current := baseline{Tests: map[string]baselineTest{
+		"ubuntu/test_ssh.json#1": {Description: "Same", Passed: true, Outputs: []baselineOutput{output(5, "bob")}},
+		"ubuntu/test_ssh.json#2": {Description: "Drifted", Passed: true, Outputs: []baselineOutput{output(5, "alice")}},
+		"ubuntu/test_ssh.json#3": {Description: "Outcome", Passed: false, Outputs: []baselineOutput{output(10, "bob")}},
+		"ubuntu/test_ssh.json#5": {Description: "Skipped", Passed: true, Status: runner.StatusSkipped, Outputs: []baselineOutput{}},
+		"ubuntu/test_ssh.json#6": {Description: "No status", Passed: true, Status: runner.StatusPassed, Outputs: []baselineOutput{output(5, "bob")}},
+		"ubuntu/test_seq.json#1": {Description: "Added", Passed: true, Outputs: []baselineOutput{output(5, "bob"), output(10, "bob")}},
+	}}
+
+	got := compareBaseline(old, current)
+	want := []baselineChange{
+		{ID: "ubuntu/test_seq.json#1", Description: "Added", Kind: baselineAdded},
+		{ID: "ubuntu/test_ssh.json#2", Description: "Drifted", Kind: baselineChanged, Details: []string{"data.srcuser: bob -> alice"}},
+		{ID: "ubuntu/test_ssh.json#3", Description: "Outcome", Kind: baselineChanged, Details: []string{"Outcome: passed -> failed", "rule.level: 5 -> 10"}},
+		{ID: "ubuntu/test_ssh.json#4", Description: "Removed", Kind: baselineRemoved},
+		{ID: "ubuntu/test_ssh.json#5", Description: "Skipped", Kind: baselineChanged, Details: []string{"Outcome: passed -> skipped"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("compareBaseline() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// Only the added test is not drift
+	if numDrifted := printBaselineComparison(console{out: io.Discard}, got, "baseline.json"); numDrifted != 4 {
+		t.Errorf("printBaselineComparison() = %d, want 4", numDrifted)
+	}
+}
+
+func Test_buildBaseline(t *testing.T) {
+	testDirs := []runner.TestDir{{
+		TestDir: testdef.TestDir{Tests: []testdef.LogTest{
+			{ID: "ubuntu/test_ssh.json#1", TestDescription: "Passed"},
+			{ID: "ubuntu/test_ssh.json#2", TestDescription: "Skipped", Skip: "flaky"},
+		}},
+		Results: []runner.Result{{Passed: true}, {Passed: true, Skipped: true}},
+	}}
+
+	got := buildBaseline(testDirs).Tests
+	if len(got) != 2 {
+		t.Fatalf("buildBaseline() saved %d tests, want 2", len(got))
+	}
+	if test := got["ubuntu/test_ssh.json#1"]; test.Status != runner.StatusPassed || len(test.Outputs) != 1 {
+		t.Errorf("buildBaseline() passed test = %+v, want passed with one output", test)
+	}
+	if test := got["ubuntu/test_ssh.json#2"]; test.Status != runner.StatusSkipped || len(test.Outputs) != 0 {
+		t.Errorf("buildBaseline() skipped test = %+v, want skipped without outputs", test)
+	}
+}
+
+func Test_pruneBaseline(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  *regexp.Regexp
+		focused bool
+		want    []string
+	}{
+		{name: "Valid everything selected", want: []string{"ssh#1", "ssh#2", "web#1"}},
+		{name: "Valid run filter", filter: regexp.MustCompile(`^ssh`), want: []string{"ssh#1", "ssh#2"}},
+		{name: "Valid focus mode", focused: true, want: []string{"ssh#1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			old := baseline{Tests: map[string]baselineTest{"ssh#1": {}, "ssh#2": {}, "web#1": {}}}
+			current := baseline{Tests: map[string]baselineTest{"ssh#1": {}}}
+
+			pruneBaseline(old, current, tt.filter, tt.focused)
+
+			var got []string
+			for id := range old.Tests {
+				got = append(got, id)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pruneBaseline() kept %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,11 @@
 package main
 
-import "os"
-
-func cliExit(numFailedTests int) {
+func cliExitCode(numFailedTests int) int {
 	// Exit with an error if at least one
 	// test failed.
 	if numFailedTests > 0 {
-		os.Exit(1)
+		return 1
 	}
 
-	os.Exit(0)
+	return 0
 }
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// A manager to run the tests against when comparing
+// managers. User and Password default to -u and -p and
+// Name defaults to the host.
+type managerProfile struct {
+	Name     string `json:"Name"`
+	Host     string `json:"Host"`
+	User     string `json:"User"`
+	Password string `json:"Password"`
+}
+
+// Loads a JSON list of manager profiles
+func loadManagerProfiles(path string) ([]managerProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []managerProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %s", path, err)
+	}
+
+	return profiles, nil
+}
+
+// Fills in the defaults of every profile and checks that
+// each one has a host and a unique name
+func resolveManagerProfiles(profiles []managerProfile, user string, password string) ([]managerProfile, error) {
+	names := map[string]struct{}{}
+	resolved := make([]managerProfile, len(profiles))
+
+	for i, profile := range profiles {
+		if profile.Host == "" {
+			return nil, fmt.Errorf("manager #%d has no host", i+1)
+		}
+		if profile.Name == "" {
+			profile.Name = profile.Host
+		}
+		if profile.User == "" {
+			profile.User = user
+		}
+		if profile.Password == "" {
+			profile.Password = password
+		}
+
+		if _, ok := names[profile.Name]; ok {
+			return nil, fmt.Errorf("manager name %s is used more than once", profile.Name)
+		}
+		names[profile.Name] = struct{}{}
+
+		resolved[i] = profile
+	}
+
+	return resolved, nil
+}
+
+// The outcome of a single test on a single manager
+type comparisonCell struct {
+	Passed  bool
+	RuleID  string
+	Level   int
+	HasRule bool
+}
+
+func (cell comparisonCell) String() string {
+	outcome := "PASS"
+	if !cell.Passed {
+		outcome = "FAIL"
+	}
+	if !cell.HasRule {
+		return outcome + " -"
+	}
+	return outcome + " " + cell.RuleID + " (" + strconv.Itoa(cell.Level) + ")"
+}
+
+// Whether two managers gave the same result
+func (cell comparisonCell) sameAs(other comparisonCell) bool {
+	return cell.Passed == other.Passed && cell.HasRule == other.HasRule &&
+		cell.RuleID == other.RuleID && cell.Level == other.Level
+}
+
+// A row of the comparison matrix. Cells[i] is the
+// result on the i-th manager.
+type comparisonRow struct {
+	ID      string
+	Name    string
+	Cells   []comparisonCell
+	Differs bool
+}
+
+// Lines up the results of every manager by test ID. Rows are
+// in the order of the first run.
+func buildComparisonMatrix(runs [][]runner.TestDir) []comparisonRow {
+	var rows []comparisonRow
+	if len(runs) == 0 {
+		return rows
+	}
+
+	// Test ID -> result, for every run
+	results := make([]map[string]runner.Result, len(runs))
+	for i, run := range runs {
+		results[i] = map[string]runner.Result{}
+		for _, dir := range run {
+			for j, test := range dir.Tests {
+				results[i][test.ID] = dir.Results[j]
+			}
+		}
+	}
+
+	for _, dir := range runs[0] {
+		for _, test := range dir.Tests {
+			row := comparisonRow{ID: test.ID, Name: test.GetDisplayName()}
+
+			for i := range runs {
+				result := results[i][test.ID]
+				rule := result.Response.Data.Output.Rule
+				cell := comparisonCell{
+					Passed:  result.Passed,
+					RuleID:  rule.ID,
+					Level:   rule.Level,
+					HasRule: rule.ID != "",
+				}
+
+				if len(row.Cells) > 0 && !cell.sameAs(row.Cells[0]) {
+					row.Differs = true
+				}
+				row.Cells = append(row.Cells, cell)
+			}
+
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// Runs the same tests against every manager at the same time
+// and prints a matrix of the results side by side
+func runCompare(ctx context.Context, args Arguments) int {
+	con := newConsole(args)
+	reporter := newConsoleReporter(con, args.Verbosity, false)
+
+	// Connect one at a time to keep the output readable
+	servers := make([]*wazuh.WazuhServer, len(args.Managers))
+	for i, profile := range args.Managers {
+		con.boldWhite("Manager: " + profile.Name)
+		con.white("Authenticating to manager: " + profile.Host)
+		ws, err := wazuh.NewWazuhServer(profile.User, profile.Password, profile.Host, args.Timeout, args.TlsLogPath)
+		if err != nil {
+			con.red("Error connecting to " + profile.Name + ": " + err.Error())
+			return 1
+		}
+		con.green("Sucessfully authenticated to manager.")
+		info, err := ws.CheckConnection()
+		if err != nil {
+			con.red("Error connecting to " + profile.Name + ": " + err.Error())
+			return 1
+		}
+		reporter.Connection(info)
+		servers[i] = ws
+	}
+
+	testDirs, err := runner.CollectTestDirs(args.TestsDir, args.RunFilter, reporter)
+	if err != nil {
+		con.red("Error running tests: " + err.Error())
+		return 1
+	}
+
+	// Every manager gets its own copy of the test tree
+	// so the results are not shared
+	runs := make([][]runner.TestDir, len(servers))
+	var wg sync.WaitGroup
+	for i, ws := range servers {
+		runs[i] = make([]runner.TestDir, len(testDirs))
+		copy(runs[i], testDirs)
+
+		wg.Add(1)
+		go func(ws *wazuh.WazuhServer, run []runner.TestDir) {
+			defer wg.Done()
+			runner.RunTestPool(ctx, ws, run, args.Threads, nil)
+		}(ws, runs[i])
+	}
+	con.white("Running tests against " + strconv.Itoa(len(servers)) + " managers...")
+	wg.Wait()
+	con.printf("\n")
+
+	// Detailed failures of each manager
+	if args.Verbosity > 0 {
+		for i, profile := range args.Managers {
+			con.boldWhite("Results for: " + profile.Name)
+			for _, dir := range runs[i] {
+				reporter.DirectoryResults(dir)
+			}
+		}
+	}
+
+	rows := buildComparisonMatrix(runs)
+	printComparisonMatrix(con, args.Managers, rows, args.DiffOnly)
+
+	totalFailed := 0
+	for i, profile := range args.Managers {
+		summary := runner.SummarizeResults(runs[i])
+		totalFailed += summary.Failed
+
+		line := fmt.Sprintf("%s: %d tests, %d failed, %d warned", profile.Name, summary.Total, summary.Failed, summary.Warned)
+		if summary.Failed > 0 {
+			con.red(line)
+		} else {
+			con.green(line)
+		}
+	}
+
+	if args.CliMode {
+		return cliExitCode(totalFailed)
+	}
+
+	return 0
+}
+
+// Prints one row per test with a column per manager. Rows
+// where the managers disagree are printed in red.
+func printComparisonMatrix(con console, managers []managerProfile, rows []comparisonRow, diffOnly bool) {
+	header := []string{"ID", "Test"}
+	for _, manager := range managers {
+		header = append(header, manager.Name)
+	}
+
+	// Size every column to its longest value
+	widths := make([]int, len(header))
+	for i, title := range header {
+		widths[i] = len(title)
+	}
+	numDiffering := 0
+	for _, row := range rows {
+		if row.Differs {
+			numDiffering++
+		}
+		if diffOnly && !row.Differs {
+			continue
+		}
+		if len(row.ID) > widths[0] {
+			widths[0] = len(row.ID)
+		}
+		if len(row.Name) > widths[1] {
+			widths[1] = len(row.Name)
+		}
+		for i, cell := range row.Cells {
+			if len(cell.String()) > widths[i+2] {
+				widths[i+2] = len(cell.String())
+			}
+		}
+	}
+
+	formatRow := func(values []string) string {
+		var sb strings.Builder
+		for i, value := range values {
+			sb.WriteString(value + strings.Repeat(" ", widths[i]-len(value)))
+			if i < len(values)-1 {
+				sb.WriteString("  ")
+			}
+		}
+		return strings.TrimRight(sb.String(), " ")
+	}
+
+	con.boldWhite("Manager Comparison:")
+	con.boldWhite("===================\n")
+
+	if diffOnly && numDiffering == 0 {
+		con.green("No differences between managers.")
+		con.printf("\n")
+		return
+	}
+
+	con.boldWhite(formatRow(header))
+	for _, row := range rows {
+		if diffOnly && !row.Differs {
+			continue
+		}
+
+		values := []string{row.ID, row.Name}
+		for _, cell := range row.Cells {
+			values = append(values, cell.String())
+		}
+
+		if row.Differs {
+			con.red(formatRow(values))
+		} else {
+			con.white(formatRow(values))
+		}
+	}
+
+	con.printf("\n")
+	con.white(strconv.Itoa(numDiffering) + " of " + strconv.Itoa(len(rows)) + " tests differ between managers")
+	con.printf("\n")
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+func Test_resolveManagerProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles []managerProfile
+		want     []managerProfile
+		wantErr  bool
+	}{
+		{
+			name:     "Valid defaults",
+			profiles: []managerProfile{{Host: "10.0.0.1"}, {Name: "4.9", Host: "10.0.0.2", User: "admin", Password: "secret"}},
+			want:     []managerProfile{{Name: "10.0.0.1", Host: "10.0.0.1", User: "wazuh", Password: "wazuh"}, {Name: "4.9", Host: "10.0.0.2", User: "admin", Password: "secret"}},
+		},
+
+		{name: "Invalid missing host", profiles: []managerProfile{{Name: "4.7"}}, wantErr: true},
+		{name: "Invalid duplicate name", profiles: []managerProfile{{Host: "10.0.0.1"}, {Name: "10.0.0.1", Host: "10.0.0.2"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := resolveManagerProfiles(tt.profiles, "wazuh", "wazuh")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveManagerProfiles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("resolveManagerProfiles()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_buildComparisonMatrix(t *testing.T) {
+	// The cases of a parameterized test share a description
+	tests := []testdef.LogTest{
+		{ID: "sshd/test_sshd.json#1", TestDescription: "SSH login to a non-existent user"},
+		{ID: "sshd/test_sshd.json#2/bob", TestDescription: "SSH login"},
+		{ID: "sshd/test_sshd.json#2/alice", TestDescription: "SSH login"},
+		{ID: "centos/test_centos.json#1", TestDescription: "Sniffing mode rule test."},
+		{ID: "centos/test_centos.json#2"},
+	}
+
+	result := func(passed bool, ruleID string, level int) runner.Result {
+		response := wazuh.Response{}
+		response.Data.Output.Rule = wazuh.Rule{ID: ruleID, Level: level}
+		return runner.Result{Passed: passed, Response: response}
+	}
+
+	oldManager := []runner.TestDir{{TestDir: testdef.TestDir{Tests: tests}, Results: []runner.Result{
+		result(true, "5710", 5),
+		result(true, "5715", 3),
+		result(true, "5715", 3),
+		result(true, "5104", 8),
+		{Passed: false, Errors: []string{"Error opening log file"}},
+	}}}
+	newManager := []runner.TestDir{{TestDir: testdef.TestDir{Tests: tests}, Results: []runner.Result{
+		result(true, "5710", 5),
+		result(true, "5715", 3),
+		result(false, "5716", 5),
+		result(false, "5104", 10),
+		{Passed: false, Errors: []string{"Error opening log file"}},
+	}}}
+
+	rows := buildComparisonMatrix([][]runner.TestDir{oldManager, newManager})
+	if len(rows) != len(tests) {
+		t.Fatalf("buildComparisonMatrix() returned %d rows, want %d", len(rows), len(tests))
+	}
+
+	want := []struct {
+		name    string
+		cells   []string
+		differs bool
+	}{
+		{name: "SSH login to a non-existent user", cells: []string{"PASS 5710 (5)", "PASS 5710 (5)"}, differs: false},
+		{name: "SSH login", cells: []string{"PASS 5715 (3)", "PASS 5715 (3)"}, differs: false},
+		{name: "SSH login", cells: []string{"PASS 5715 (3)", "FAIL 5716 (5)"}, differs: true},
+		{name: "Sniffing mode rule test.", cells: []string{"PASS 5104 (8)", "FAIL 5104 (10)"}, differs: true},
+		{name: "RuleID  test", cells: []string{"FAIL -", "FAIL -"}, differs: false},
+	}
+	for i, row := range rows {
+		if row.ID != tests[i].ID || row.Name != want[i].name {
+			t.Errorf("row %d = %q %q, want %q %q", i, row.ID, row.Name, tests[i].ID, want[i].name)
+		}
+		if row.Differs != want[i].differs {
+			t.Errorf("row %d differs = %v, want %v", i, row.Differs, want[i].differs)
+		}
+		for j, cell := range row.Cells {
+			if cell.String() != want[i].cells[j] {
+				t.Errorf("row %d cell %d = %q, want %q", i, j, cell.String(), want[i].cells[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// Coverage of a single MITRE ATT&CK technique by the passing tests
+type techniqueCoverage struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name,omitempty"`
+	Tactics []string `json:"tactics,omitempty"`
+	RuleIDs []string `json:"rule_ids"`
+	Tests   []string `json:"tests"`
+}
+
+// Coverage of a single compliance control (e.g. PCI DSS 10.2.4)
+type controlCoverage struct {
+	Framework string   `json:"framework"`
+	Control   string   `json:"control"`
+	RuleIDs   []string `json:"rule_ids"`
+	Tests     []string `json:"tests"`
+}
+
+// A technique that is referenced by the manager's
+// ruleset but not exercised by any passing test
+type untestedTechnique struct {
+	ID      string   `json:"id"`
+	RuleIDs []string `json:"rule_ids"`
+}
+
+type complianceCoverage struct {
+	Techniques         []techniqueCoverage `json:"techniques"`
+	Controls           []controlCoverage   `json:"controls"`
+	UntestedTechniques []untestedTechnique `json:"untested_techniques"`
+
+	// False when the ruleset could not be fetched from the
+	// manager and untested techniques are unknown
+	RulesetChecked bool `json:"ruleset_checked"`
+}
+
+// Builds the coverage report from the passing tests and writes
+// it to path. The ruleset is fetched from the manager to find
+// techniques that no test exercises. ws is nil when the tests
+// did not run against a manager.
+func writeComplianceReport(con console, ws *wazuh.WazuhServer, testDirs []runner.TestDir, path string) error {
+	writeReport, err := getComplianceWriter(path)
+	if err != nil {
+		return err
+	}
+
+	coverage := buildComplianceCoverage(testDirs)
+
+	if ws != nil {
+		rules, err := ws.GetRules(nil)
+		if err != nil {
+			con.yellow("WARNING: Unable to fetch ruleset, untested techniques will not be reported: " + err.Error())
+		} else {
+			addUntestedTechniques(&coverage, rules)
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return writeReport(file, coverage)
+}
+
+// Pick the report format from the file extension
+func getComplianceWriter(path string) (func(io.Writer, complianceCoverage) error, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md":
+		return writeComplianceMarkdown, nil
+	case ".csv":
+		return writeComplianceCSV, nil
+	case ".json":
+		return writeComplianceJSON, nil
+	}
+
+	return nil, fmt.Errorf("unsupported compliance report format: %s (use .md, .csv or .json)", path)
+}
+
+// The controls of a single compliance framework
+// that a rule is mapped to
+type frameworkControls struct {
+	Framework string
+	Controls  []string
+}
+
+// The compliance frameworks reported in the rule object
+// of a logtest response
+func getRuleControls(rule wazuh.Rule) []frameworkControls {
+	return []frameworkControls{
+		{"PCI DSS", rule.PciDss},
+		{"GDPR", rule.Gdpr},
+		{"HIPAA", rule.Hipaa},
+		{"NIST 800-53", rule.Nist80053},
+		{"GPG13", rule.Gpg13},
+		{"TSC", rule.Tsc},
+	}
+}
+
+// Collects the techniques and controls of the rules
+// that fired for every passing test
+func buildComplianceCoverage(testDirs []runner.TestDir) complianceCoverage {
+	techniques := map[string]*techniqueCoverage{}
+	controls := map[string]*controlCoverage{}
+	ruleIDs := map[string]map[string]struct{}{}
+	tests := map[string]map[string]struct{}{}
+	tactics := map[string]map[string]struct{}{}
+
+	addTo := func(sets map[string]map[string]struct{}, key string, val string) {
+		if _, ok := sets[key]; !ok {
+			sets[key] = map[string]struct{}{}
+		}
+		sets[key][val] = struct{}{}
+	}
+
+	for _, testDir := range testDirs {
+		for i, result := range testDir.Results {
+			if !result.Passed {
+				continue
+			}
+			testName := testDir.Tests[i].GetDisplayName()
+
+			for _, response := range result.GetResponses() {
+				rule := response.Data.Output.Rule
+				if rule.ID == "" {
+					continue
+				}
+
+				for j, id := range rule.Mitre.ID {
+					key := "mitre:" + id
+					if _, ok := techniques[key]; !ok {
+						techniques[key] = &techniqueCoverage{ID: id}
+					}
+
+					// Technique names line up with the IDs
+					if j < len(rule.Mitre.Technique) && techniques[key].Name == "" {
+						techniques[key].Name = rule.Mitre.Technique[j]
+					}
+
+					for _, tactic := range rule.Mitre.Tactic {
+						addTo(tactics, key, tactic)
+					}
+					addTo(ruleIDs, key, rule.ID)
+					addTo(tests, key, testName)
+				}
+
+				for _, framework := range getRuleControls(rule) {
+					for _, control := range framework.Controls {
+						key := framework.Framework + ":" + control
+						if _, ok := controls[key]; !ok {
+							controls[key] = &controlCoverage{Framework: framework.Framework, Control: control}
+						}
+						addTo(ruleIDs, key, rule.ID)
+						addTo(tests, key, testName)
+					}
+				}
+			}
+		}
+	}
+
+	coverage := complianceCoverage{
+		Techniques:         []techniqueCoverage{},
+		Controls:           []controlCoverage{},
+		UntestedTechniques: []untestedTechnique{},
+	}
+
+	for key, technique := range techniques {
+		technique.Tactics = sortedSet(tactics[key])
+		technique.RuleIDs = sortedRuleIDs(ruleIDs[key])
+		technique.Tests = sortedSet(tests[key])
+		coverage.Techniques = append(coverage.Techniques, *technique)
+	}
+	sort.Slice(coverage.Techniques, func(i, j int) bool {
+		return coverage.Techniques[i].ID < coverage.Techniques[j].ID
+	})
+
+	for key, control := range controls {
+		control.RuleIDs = sortedRuleIDs(ruleIDs[key])
+		control.Tests = sortedSet(tests[key])
+		coverage.Controls = append(coverage.Controls, *control)
+	}
+	sort.Slice(coverage.Controls, func(i, j int) bool {
+		if coverage.Controls[i].Framework != coverage.Controls[j].Framework {
+			return coverage.Controls[i].Framework < coverage.Controls[j].Framework
+		}
+		return coverage.Controls[i].Control < coverage.Controls[j].Control
+	})
+
+	return coverage
+}
+
+// Adds every technique in the ruleset that is not
+// covered by a passing test
+func addUntestedTechniques(coverage *complianceCoverage, rules []wazuh.ManagerRule) {
+	covered := map[string]struct{}{}
+	for _, technique := range coverage.Techniques {
+		covered[technique.ID] = struct{}{}
+	}
+
+	untested := map[string]map[string]struct{}{}
+	for _, rule := range rules {
+		for _, id := range rule.Mitre {
+			if _, ok := covered[id]; ok {
+				continue
+			}
+			if _, ok := untested[id]; !ok {
+				untested[id] = map[string]struct{}{}
+			}
+			untested[id][strconv.Itoa(rule.ID)] = struct{}{}
+		}
+	}
+
+	coverage.UntestedTechniques = []untestedTechnique{}
+	for id, ruleIDs := range untested {
+		coverage.UntestedTechniques = append(coverage.UntestedTechniques, untestedTechnique{ID: id, RuleIDs: sortedRuleIDs(ruleIDs)})
+	}
+	sort.Slice(coverage.UntestedTechniques, func(i, j int) bool {
+		return coverage.UntestedTechniques[i].ID < coverage.UntestedTechniques[j].ID
+	})
+
+	coverage.RulesetChecked = true
+}
+
+func writeComplianceMarkdown(w io.Writer, coverage complianceCoverage) error {
+	var sb strings.Builder
+
+	sb.WriteString("# MITRE ATT&CK and Compliance Coverage\n\n")
+	sb.WriteString("Built from the rule metadata of every passing test.\n\n")
+
+	sb.WriteString("## MITRE ATT&CK Techniques\n\n")
+	if len(coverage.Techniques) == 0 {
+		sb.WriteString("No techniques are covered by passing tests.\n\n")
+	} else {
+		sb.WriteString("| Technique | Name | Tactics | Rule IDs | Tests |\n")
+		sb.WriteString("|-----------|------|---------|----------|-------|\n")
+		for _, t := range coverage.Techniques {
+			sb.WriteString("| " + markdownCell(t.ID) + " | " + markdownCell(t.Name) + " | " + markdownCell(strings.Join(t.Tactics, ", ")) + " | " + markdownCell(strings.Join(t.RuleIDs, ", ")) + " | " + markdownCell(strings.Join(t.Tests, "<br>")) + " |\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Techniques Not Covered by Tests\n\n")
+	if !coverage.RulesetChecked {
+		sb.WriteString("The ruleset could not be fetched from the manager.\n\n")
+	} else if len(coverage.UntestedTechniques) == 0 {
+		sb.WriteString("Every technique referenced in the ruleset is covered.\n\n")
+	} else {
+		sb.WriteString("| Technique | Rule IDs |\n")
+		sb.WriteString("|-----------|----------|\n")
+		for _, t := range coverage.UntestedTechniques {
+			sb.WriteString("| **" + markdownCell(t.ID) + "** | " + markdownCell(strings.Join(t.RuleIDs, ", ")) + " |\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	// One section per compliance framework
+	framework := ""
+	for _, c := range coverage.Controls {
+		if c.Framework != framework {
+			if framework != "" {
+				sb.WriteString("\n")
+			}
+			framework = c.Framework
+			sb.WriteString("## " + framework + "\n\n")
+			sb.WriteString("| Control | Rule IDs | Tests |\n")
+			sb.WriteString("|---------|----------|-------|\n")
+		}
+		sb.WriteString("| " + markdownCell(c.Control) + " | " + markdownCell(strings.Join(c.RuleIDs, ", ")) + " | " + markdownCell(strings.Join(c.Tests, "<br>")) + " |\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// One row per technique or control. Untested techniques
+// have an empty tests column and tested set to false.
+func writeComplianceCSV(w io.Writer, coverage complianceCoverage) error {
+	writer := csv.NewWriter(w)
+
+	rows := [][]string{{"framework", "id", "name", "tactics", "rule_ids", "tests", "tested"}}
+	for _, t := range coverage.Techniques {
+		rows = append(rows, []string{"MITRE ATT&CK", t.ID, t.Name, strings.Join(t.Tactics, ";"), strings.Join(t.RuleIDs, ";"), strings.Join(t.Tests, ";"), "true"})
+	}
+	for _, t := range coverage.UntestedTechniques {
+		rows = append(rows, []string{"MITRE ATT&CK", t.ID, "", "", strings.Join(t.RuleIDs, ";"), "", "false"})
+	}
+	for _, c := range coverage.Controls {
+		rows = append(rows, []string{c.Framework, c.Control, "", "", strings.Join(c.RuleIDs, ";"), strings.Join(c.Tests, ";"), "true"})
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return writer.Error()
+}
+
+func writeComplianceJSON(w io.Writer, coverage complianceCoverage) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(coverage)
+}
+
+// Escapes text for use inside a Markdown table cell
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	text = strings.ReplaceAll(text, "\n", " ")
+	return text
+}
+
+func sortedSet(set map[string]struct{}) []string {
+	values := make([]string, 0, len(set))
+	for val := range set {
+		values = append(values, val)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// Rule IDs are sorted numerically rather than as strings
+func sortedRuleIDs(set map[string]struct{}) []string {
+	values := sortedSet(set)
+	sort.SliceStable(values, func(i, j int) bool {
+		a, errA := strconv.Atoi(values[i])
+		b, errB := strconv.Atoi(values[j])
+		if errA != nil || errB != nil {
+			return values[i] < values[j]
+		}
+		return a < b
+	})
+	return values
+}
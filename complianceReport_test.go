@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+func Test_buildComplianceCoverage(t *testing.T) {
+	sshRule := wazuh.Rule{
+		ID:     "5710",
+		Level:  5,
+		Mitre:  wazuh.Mitre{ID: []string{"T1110.001"}, Tactic: []string{"Credential Access"}, Technique: []string{"Password Guessing"}},
+		PciDss: []string{"10.2.4"},
+	}
+	testDirs := []runner.TestDir{
+		{
+			TestDir: testdef.TestDir{
+				Path:  "tests/ubuntu",
+				Tests: []testdef.LogTest{{TestDescription: "SSH login to a non-existent user"}, {TestDescription: "Failing SSH test"}},
+			},
+			Results: []runner.Result{
+				{Passed: true, Response: wazuh.Response{Data: wazuh.Data{Output: wazuh.Output{Rule: sshRule}}}},
+				{Passed: false, Response: wazuh.Response{Data: wazuh.Data{Output: wazuh.Output{Rule: wazuh.Rule{ID: "5712", Mitre: wazuh.Mitre{ID: []string{"T1110"}}}}}}},
+			},
+		},
+	}
+
+	coverage := buildComplianceCoverage(testDirs)
+
+	wantTechniques := []techniqueCoverage{{
+		ID:      "T1110.001",
+		Name:    "Password Guessing",
+		Tactics: []string{"Credential Access"},
+		RuleIDs: []string{"5710"},
+		Tests:   []string{"SSH login to a non-existent user"},
+	}}
+	if !reflect.DeepEqual(coverage.Techniques, wantTechniques) {
+		t.Errorf("buildComplianceCoverage() techniques = %+v, want %+v", coverage.Techniques, wantTechniques)
+	}
+
+	wantControls := []controlCoverage{{Framework: "PCI DSS", Control: "10.2.4", RuleIDs: []string{"5710"}, Tests: []string{"SSH login to a non-existent user"}}}
+	if !reflect.DeepEqual(coverage.Controls, wantControls) {
+		t.Errorf("buildComplianceCoverage() controls = %+v, want %+v", coverage.Controls, wantControls)
+	}
+
+	// The failing test's technique is only in the ruleset
+	addUntestedTechniques(&coverage, []wazuh.ManagerRule{
+		{ID: 5710, Mitre: []string{"T1110.001"}},
+		{ID: 5712, Mitre: []string{"T1110"}},
+		{ID: 5720, Mitre: []string{"T1110"}},
+	})
+
+	wantUntested := []untestedTechnique{{ID: "T1110", RuleIDs: []string{"5712", "5720"}}}
+	if !reflect.DeepEqual(coverage.UntestedTechniques, wantUntested) {
+		t.Errorf("addUntestedTechniques() = %+v, want %+v", coverage.UntestedTechniques, wantUntested)
+	}
+
+	var sb strings.Builder
+	if err := writeComplianceMarkdown(&sb, coverage); err != nil {
+		t.Fatalf("writeComplianceMarkdown() error = %v", err)
+	}
+	if !strings.Contains(sb.String(), "| **T1110** | 5712, 5720 |") {
+		t.Errorf("writeComplianceMarkdown() does not highlight the untested technique:\n%s", sb.String())
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/schollz/progressbar/v3"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// The colored console output of a test run
+type consoleReporter struct {
+	console
+	verbosity int
+
+	// Show a progress bar while the tests run
+	progress bool
+	bar      *progressbar.ProgressBar
+
+	numTests     int
+	numCompleted int
+}
+
+// The progress bar is only shown when the output is a
+// terminal
+func newConsoleReporter(con console, verbosity int, progress bool) *consoleReporter {
+	file, ok := con.out.(*os.File)
+	return &consoleReporter{
+		console:   con,
+		verbosity: verbosity,
+		progress:  progress && ok && isTerminal(file),
+	}
+}
+
+func (c *consoleReporter) Connection(info wazuh.ManagerInfo) {
+	c.white("Verifying connection to manager...")
+
+	if c.verbosity > 1 {
+		c.white("Wazuh API version: " + info.APIVersion + " (revision: " + strconv.FormatFloat(info.Revision, 'f', -1, 64) + ")")
+	} else if c.verbosity > 0 {
+		c.white("Wazuh API version: " + info.APIVersion)
+	}
+	c.green("Verified connection to manager.")
+
+	fmt.Fprintf(c.out, "\n\n")
+}
+
+// Prints the errors of a test that failed to load and, with
+// -vv, the warnings of any test
+func (c *consoleReporter) LoadIssue(issue testdef.LoadIssue) {
+	header := issue.Path + ": Test #" + strconv.Itoa(issue.Index+1)
+	if issue.RuleID != "" {
+		header = "Test: (RuleID: " + issue.RuleID + ") " + issue.Description
+	} else if issue.CaseName != "" {
+		header += " [" + issue.CaseName + "]"
+	}
+
+	valid := len(issue.Errors) == 0
+	if !valid {
+		c.red("[FAILED LOAD] " + header)
+
+		if c.verbosity < 1 {
+			return
+		}
+
+		// Print Errors for: -v (1), -vv (2)
+		// Tab over to show that these are errors
+		// corresponding to the test above
+		for _, e := range issue.Errors {
+			c.red("+ " + e)
+		}
+		fmt.Fprintf(c.out, "\n")
+	}
+
+	// We will print the warning header if verboisty 2 (-vv)
+	// and we haven't already printed the failed load header
+	var hasWarnings bool = (len(issue.Warnings) > 0)
+	if valid && hasWarnings && c.verbosity > 1 {
+		c.yellow("[LOAD WARNING] " + header)
+	}
+
+	if hasWarnings && c.verbosity > 1 {
+		for _, w := range issue.Warnings {
+			c.yellow("+ " + w)
+		}
+		fmt.Fprintf(c.out, "\n")
+	}
+}
+
+func (c *consoleReporter) Warning(message string) {
+	c.yellow("WARNING: " + message)
+}
+
+func (c *consoleReporter) RunStart(testDirs []runner.TestDir) {
+	c.numTests = 0
+	c.numCompleted = 0
+	for _, dir := range testDirs {
+		c.numTests += len(dir.Tests)
+	}
+
+	if c.progress {
+		c.bar = progressbar.NewOptions(c.numTests, progressbar.OptionSetWriter(c.out), progressbar.OptionSetDescription("Running tests"), progressbar.OptionShowCount())
+	}
+
+	if c.numTests == 0 {
+		fmt.Fprintf(c.out, "\n")
+	}
+}
+
+func (c *consoleReporter) TestResult(test testdef.LogTest, result runner.Result) {
+	c.numCompleted++
+	if c.bar != nil {
+		_ = c.bar.Add(1)
+	}
+
+	// End the progress bar line
+	if c.numCompleted == c.numTests {
+		fmt.Fprintf(c.out, "\n")
+	}
+}
+
+// Prints the failures and warnings of a single test directory
+func (c *consoleReporter) DirectoryResults(testDir runner.TestDir) {
+	verbosity := c.verbosity
+
+	if len(testDir.Tests) > 0 && verbosity > 0 {
+		c.boldWhite("Ran tests in: " + testDir.Path)
+		totalTests := len(testDir.Tests) + testDir.InvalidTests
+		c.white("Sucessfully loaded " + strconv.Itoa(len(testDir.Tests)) + "/" + strconv.Itoa(totalTests) + " tests")
+	}
+
+	// There should be a one to one mapping of
+	// LogTest objects to log files. This means
+	// that the number of other files should be
+	// greater than or equal to the number of LogTest
+	// objects for the current directory.
+	//
+	// Warn the users so they are aware when
+	// interpreting the results. Sequence tests
+	// can have their logs inline so are not counted
+	// and the cases of a parameterized test count once.
+	numFileTests := 0
+	counted := map[string]struct{}{}
+	for _, test := range testDir.Tests {
+		definition := test.DefPath + "#" + strconv.Itoa(test.DefIndex)
+		if _, ok := counted[definition]; ok || test.IsSequence() {
+			continue
+		}
+		counted[definition] = struct{}{}
+		numFileTests++
+	}
+	if testDir.NumLogFiles < numFileTests {
+		diff := numFileTests - testDir.NumLogFiles
+		c.yellow("WARNING: " + testDir.Path + " has " + strconv.Itoa(diff) + " more tests than log files...")
+	}
+
+	for i, test := range testDir.Tests {
+		printedHeader := false
+		testErrors := testDir.Results[i].Errors
+		testWarnings := testDir.Results[i].Warnings
+
+		switch {
+		case testDir.Results[i].Skipped:
+			if verbosity > 0 {
+				c.white("[SKIPPED] Test: " + test.ID + " (" + test.GetRuleLabel() + ") " + test.GetTestDescription() + ": " + test.Skip)
+			}
+			continue
+		case testDir.Results[i].UnexpectedPass:
+			printedHeader = true
+			c.yellow("[UNEXPECTEDLY PASSED] Test: " + test.ID + " (" + test.GetRuleLabel() + ") " + test.GetTestDescription() + ": " + test.ExpectFail)
+		case testDir.Results[i].ExpectedFail:
+			printedHeader = true
+			if verbosity > 0 {
+				c.white("[EXPECTED FAIL] Test: " + test.ID + " (" + test.GetRuleLabel() + ") " + test.GetTestDescription() + ": " + test.ExpectFail)
+			}
+			if verbosity > 1 {
+				for _, e := range testErrors {
+					c.white("+ " + e + "\n")
+				}
+			}
+		case len(testErrors) > 0:
+			printedHeader = true
+			c.red("[FAILED] Test: " + test.ID + " (" + test.GetRuleLabel() + ") " + test.GetTestDescription())
+			for _, e := range testErrors {
+				c.red("+ " + e + "\n")
+			}
+		}
+		if verbosity > 1 && len(testWarnings) > 0 {
+			// Only print warnings header if there were no errors
+			if !printedHeader {
+				c.yellow("[WARNING] Test: " + test.ID + " (" + test.GetRuleLabel() + ") " + test.GetTestDescription())
+			}
+			for _, w := range testWarnings {
+				c.yellow("+ " + w + "\n")
+			}
+		}
+	}
+
+	if len(testDir.Tests) > 0 {
+		fmt.Fprintf(c.out, "\n\n")
+	}
+}
+
+func (c *consoleReporter) Summary(testDirs []runner.TestDir, summary runner.Summary) {
+	c.boldWhite("Test Summary:")
+	c.boldWhite("=============\n")
+
+	fmt.Fprintf(c.out, "Total: %d\n", summary.Total)
+
+	if summary.Failed > 0 {
+		c.red("Failed: " + strconv.Itoa(summary.Failed))
+	}
+
+	if summary.Warned > 0 {
+		c.yellow("Warned: " + strconv.Itoa(summary.Warned))
+	}
+
+	if summary.Skipped > 0 {
+		c.white("Skipped: " + strconv.Itoa(summary.Skipped))
+	}
+
+	if summary.ExpectedFailures > 0 {
+		c.white("Expected failures: " + strconv.Itoa(summary.ExpectedFailures))
+	}
+
+	if summary.UnexpectedPasses > 0 {
+		c.yellow("Unexpectedly passed: " + strconv.Itoa(summary.UnexpectedPasses))
+	}
+
+	fmt.Fprintf(c.out, "\n")
+
+	if summary.Failed <= 0 {
+		c.green("All tests passed.")
+	}
+}
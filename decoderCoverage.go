@@ -0,0 +1,260 @@
+package main
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// Coverage of every decoder sharing a single name
+type decoderCoverage struct {
+	Name   string
+	Parent string
+	Files  []string
+
+	// Fields from the decoders' order definitions
+	Fields []string
+
+	// True when the decoder (or one of its children)
+	// was used for at least one test
+	Tested bool
+
+	// Fields that are not asserted by any test that used
+	// the decoder
+	UnassertedFields []string
+}
+
+type decoderCoverageReport struct {
+	Decoders []decoderCoverage
+}
+
+// Fetch the decoder catalog and compare it with the decoders
+// that were used during the test run. The file and directory
+// filters are optional.
+func getDecoderCoverage(ws *wazuh.WazuhServer, testDirs []runner.TestDir, decoderFilename string, decoderDirname string) (decoderCoverageReport, error) {
+	filters := url.Values{}
+	if decoderFilename != "" {
+		filters.Set("filename", decoderFilename)
+	}
+	if decoderDirname != "" {
+		filters.Set("relative_dirname", decoderDirname)
+	}
+
+	decoders, err := ws.GetDecoders(filters)
+	if err != nil {
+		return decoderCoverageReport{}, err
+	}
+
+	return buildDecoderCoverage(decoders, testDirs), nil
+}
+
+func buildDecoderCoverage(decoders []wazuh.ManagerDecoder, testDirs []runner.TestDir) decoderCoverageReport {
+	// Decoder name -> fields asserted by the tests that used it
+	used := map[string]map[string]struct{}{}
+
+	markUsed := func(name string, asserted []string) {
+		if name == "" {
+			return
+		}
+		if _, ok := used[name]; !ok {
+			used[name] = map[string]struct{}{}
+		}
+		for _, field := range asserted {
+			used[name][field] = struct{}{}
+		}
+	}
+
+	for _, testDir := range testDirs {
+		for i, result := range testDir.Results {
+			test := testDir.Tests[i]
+			if test.IsSequence() {
+				events := test.GetSequenceEvents()
+				for j, response := range result.Steps {
+					decoder := response.Data.Output.Decoder
+					asserted := getAssertedFields(events[j].Decoder, events[j].Data)
+					markUsed(decoder["name"], asserted)
+					markUsed(decoder["parent"], asserted)
+				}
+				continue
+			}
+
+			decoder := result.Response.Data.Output.Decoder
+			asserted := getAssertedFields(test.GetDecoder(), test.GetData())
+
+			// A child decoder also exercises its parent
+			markUsed(decoder["name"], asserted)
+			markUsed(decoder["parent"], asserted)
+		}
+	}
+
+	// Merge sibling decoders that share a name
+	byName := map[string]*decoderCoverage{}
+	files := map[string]map[string]struct{}{}
+	fields := map[string]map[string]struct{}{}
+	for _, decoder := range decoders {
+		coverage, ok := byName[decoder.Name]
+		if !ok {
+			coverage = &decoderCoverage{Name: decoder.Name}
+			byName[decoder.Name] = coverage
+			files[decoder.Name] = map[string]struct{}{}
+			fields[decoder.Name] = map[string]struct{}{}
+		}
+
+		// Siblings can name themselves as parent
+		if parent := decoder.GetParent(); coverage.Parent == "" && parent != decoder.Name {
+			coverage.Parent = parent
+		}
+
+		file := decoder.Filename
+		if decoder.RelativeDirname != "" {
+			file = decoder.RelativeDirname + "/" + decoder.Filename
+		}
+		files[decoder.Name][file] = struct{}{}
+
+		for _, field := range decoder.GetOrderFields() {
+			fields[decoder.Name][field] = struct{}{}
+		}
+	}
+
+	report := decoderCoverageReport{}
+	for name, coverage := range byName {
+		coverage.Files = sortedSet(files[name])
+		coverage.Fields = sortedSet(fields[name])
+
+		asserted, tested := used[name]
+		coverage.Tested = tested
+
+		for _, field := range coverage.Fields {
+			if _, ok := asserted[field]; !ok {
+				coverage.UnassertedFields = append(coverage.UnassertedFields, field)
+			}
+		}
+
+		report.Decoders = append(report.Decoders, *coverage)
+	}
+
+	sort.Slice(report.Decoders, func(i, j int) bool {
+		return report.Decoders[i].Name < report.Decoders[j].Name
+	})
+
+	return report
+}
+
+// The decoded fields a test makes assertions on. Both the
+// Decoder and Data expectations can name decoded fields.
+func getAssertedFields(decoder map[string]string, data map[string]interface{}) []string {
+	var fields []string
+
+	for key := range decoder {
+		fields = append(fields, key)
+	}
+
+	for key := range data {
+		fields = append(fields, key)
+	}
+
+	return fields
+}
+
+func (report decoderCoverageReport) numTested() int {
+	tested := 0
+	for _, decoder := range report.Decoders {
+		if decoder.Tested {
+			tested++
+		}
+	}
+	return tested
+}
+
+// Percentage of decoder names that were used by at least
+// one test. An empty catalog is fully covered.
+func (report decoderCoverageReport) percentage() float64 {
+	if len(report.Decoders) == 0 {
+		return 100
+	}
+
+	return float64(report.numTested()) / float64(len(report.Decoders)) * 100
+}
+
+func printDecoderCoverage(con console, report decoderCoverageReport, verbosity int) {
+	con.boldWhite("Decoder Coverage:")
+	con.boldWhite("=================\n")
+
+	numUntested := len(report.Decoders) - report.numTested()
+
+	con.printf("Decoders: %d\n", len(report.Decoders))
+	con.printf("Tested: %d\n", report.numTested())
+	if numUntested > 0 {
+		con.yellow("Untested: " + strconv.Itoa(numUntested))
+	}
+	con.printf("Coverage: %.1f%%\n\n", report.percentage())
+
+	// Group untested children under their parents
+	if numUntested > 0 {
+		con.yellow("Untested decoders:")
+		children := map[string][]decoderCoverage{}
+		var roots []decoderCoverage
+		for _, decoder := range report.Decoders {
+			if decoder.Tested {
+				continue
+			}
+			if decoder.Parent == "" {
+				roots = append(roots, decoder)
+			} else {
+				children[decoder.Parent] = append(children[decoder.Parent], decoder)
+			}
+		}
+
+		for _, root := range roots {
+			con.yellow("  " + root.Name + " (" + strings.Join(root.Files, ", ") + ")")
+			for _, child := range children[root.Name] {
+				con.yellow("    " + child.Name + " (" + strings.Join(child.Files, ", ") + ")")
+			}
+			delete(children, root.Name)
+		}
+
+		// Untested children of tested parents
+		parents := make([]string, 0, len(children))
+		for parent := range children {
+			parents = append(parents, parent)
+		}
+		sort.Strings(parents)
+		for _, parent := range parents {
+			for _, child := range children[parent] {
+				con.yellow("  " + parent + " > " + child.Name + " (" + strings.Join(child.Files, ", ") + ")")
+			}
+		}
+		con.printf("\n")
+	}
+
+	// Only tested decoders are listed here since every
+	// field of an untested decoder is unasserted
+	var unasserted []decoderCoverage
+	for _, decoder := range report.Decoders {
+		if decoder.Tested && len(decoder.UnassertedFields) > 0 {
+			unasserted = append(unasserted, decoder)
+		}
+	}
+
+	if len(unasserted) > 0 {
+		con.yellow("Decoders with fields not asserted by any test:")
+		for _, decoder := range unasserted {
+			con.yellow("  " + decoder.Name + ": " + strings.Join(decoder.UnassertedFields, ", "))
+		}
+		con.printf("\n")
+	}
+
+	if verbosity > 0 {
+		con.white("Tested decoders:")
+		for _, decoder := range report.Decoders {
+			if decoder.Tested {
+				con.white("  " + decoder.Name)
+			}
+		}
+		con.printf("\n")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+func Test_buildDecoderCoverage(t *testing.T) {
+	decoders := []wazuh.ManagerDecoder{
+		{Name: "sshd", Filename: "0310-ssh_decoders.xml", Details: map[string]interface{}{"program_name": "^sshd"}},
+		{Name: "sshd", Filename: "0310-ssh_decoders.xml", Details: map[string]interface{}{"parent": "sshd", "order": "srcuser, srcip, srcport"}},
+		{Name: "sshd-success", Filename: "0310-ssh_decoders.xml", Details: map[string]interface{}{"parent": "sshd", "order": "user, srcip"}},
+		{Name: "kernel", Filename: "0100-kernel_decoders.xml", Details: map[string]interface{}{"program_name": "^kernel"}},
+	}
+
+	testDirs := []runner.TestDir{
+		{
+			TestDir: testdef.TestDir{Tests: []testdef.LogTest{{Decoder: map[string]string{"srcip": "10.0.0.4"}, Data: map[string]interface{}{"srcuser": "root"}}}},
+			Results: []runner.Result{
+				{Passed: true, Response: wazuh.Response{Data: wazuh.Data{Output: wazuh.Output{Decoder: map[string]string{"name": "sshd", "parent": "sshd"}}}}},
+			},
+		},
+	}
+
+	report := buildDecoderCoverage(decoders, testDirs)
+
+	want := []decoderCoverage{
+		{Name: "kernel", Files: []string{"0100-kernel_decoders.xml"}, Fields: []string{}, Tested: false},
+		{Name: "sshd", Files: []string{"0310-ssh_decoders.xml"}, Fields: []string{"srcip", "srcport", "srcuser"}, Tested: true, UnassertedFields: []string{"srcport"}},
+		{Name: "sshd-success", Parent: "sshd", Files: []string{"0310-ssh_decoders.xml"}, Fields: []string{"srcip", "user"}, Tested: false, UnassertedFields: []string{"srcip", "user"}},
+	}
+	if !reflect.DeepEqual(report.Decoders, want) {
+		t.Errorf("buildDecoderCoverage() = %+v, want %+v", report.Decoders, want)
+	}
+
+	if report.numTested() != 1 {
+		t.Errorf("numTested() = %v, want 1", report.numTested())
+	}
+}
@@ -3,10 +3,12 @@ module github.com/alexchristy/WazuhTest
 go 1.22.3
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
+	github.com/schollz/progressbar/v3 v3.14.3
+)
+
+require (
 	github.com/mitchellh/colorstring v0.0.0-20190213212951-d06e56a500db // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	github.com/schollz/progressbar/v3 v3.14.3 // indirect
 	golang.org/x/sys v0.20.0 // indirect
 	golang.org/x/term v0.20.0 // indirect
 )
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"os"
+
+	"github.com/alexchristy/WazuhTest/runner"
+)
+
+// Labels shown for each test status
+var statusLabels = map[string]string{
+	runner.StatusPassed:         "Passed",
+	runner.StatusFailed:         "Failed",
+	runner.StatusSkipped:        "Skipped",
+	runner.StatusExpectedFail:   "Expected fail",
+	runner.StatusUnexpectedPass: "Unexpectedly passed",
+}
+
+// Statuses in the order the filters are shown
+var reportStatuses = []string{runner.StatusFailed, runner.StatusUnexpectedPass, runner.StatusPassed, runner.StatusExpectedFail, runner.StatusSkipped}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"statusLabel":    func(status string) string { return statusLabels[status] },
+	"formatDuration": runner.FormatDuration,
+	"statusCounts":   countStatuses,
+	"statuses":       func() []string { return reportStatuses },
+	"inc":            func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>WazuhTest Report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1, h2 { font-weight: normal; }
+h2 { font-size: 1.1em; margin-top: 2em; word-break: break-all; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 0.3em 0.6em; border-bottom: 1px solid #ddd; vertical-align: top; }
+th { background: #f4f4f4; }
+table.summary, table.fields { width: auto; }
+pre { background: #f7f7f7; padding: 0.6em; overflow-x: auto; white-space: pre-wrap; word-break: break-all; }
+summary { cursor: pointer; }
+.status { font-weight: bold; white-space: nowrap; }
+.passed { color: #1a7f37; }
+.failed { color: #cf222e; }
+.skipped, .expected-fail { color: #666; }
+.unexpected-pass { color: #9a6700; }
+.filters label { margin-right: 1em; }
+.error { color: #cf222e; }
+.warning { color: #9a6700; }
+.hidden { display: none; }
+</style>
+</head>
+<body>
+<h1>WazuhTest Report</h1>
+<p>Generated {{.Generated}}</p>
+
+<table class="summary">
+<tr><th>Total tests</th><td>{{.Summary.Total}}</td></tr>
+<tr><th>Failed</th><td class="failed">{{.Summary.Failed}}</td></tr>
+<tr><th>Warned</th><td class="warning">{{.Summary.Warned}}</td></tr>
+<tr><th>Skipped</th><td>{{.Summary.Skipped}}</td></tr>
+<tr><th>Expected failures</th><td>{{.Summary.ExpectedFailures}}</td></tr>
+<tr><th>Unexpectedly passed</th><td class="unexpected-pass">{{.Summary.UnexpectedPasses}}</td></tr>
+</table>
+
+{{$counts := statusCounts .}}
+<p class="filters">Show:
+{{range statuses}}<label><input type="checkbox" value="{{.}}" checked onchange="filterTests()"> <span class="{{.}}">{{statusLabel .}}</span> ({{index $counts .}})</label>
+{{end}}</p>
+
+{{range .Dirs}}
+<h2>{{.Path}}</h2>
+{{if .InvalidTests}}<p class="error">{{.InvalidTests}} invalid tests were not run</p>{{end}}
+{{if .LoadIssues}}<ul>
+{{range .LoadIssues}}{{$name := .GetName}}{{range .Errors}}<li class="error">{{$name}}: {{.}}</li>
+{{end}}{{range .Warnings}}<li class="warning">{{$name}}: {{.}}</li>
+{{end}}{{end}}</ul>{{end}}
+<table>
+<tr><th>ID</th><th>Rule</th><th>Test</th><th>Status</th><th>Duration</th></tr>
+{{range .Tests}}
+<tbody class="test" data-status="{{.Status}}">
+<tr>
+<td>{{.ID}}</td>
+<td>{{.Label}}</td>
+<td>{{.Description}}</td>
+<td class="status {{.Status}}">{{statusLabel .Status}}</td>
+<td>{{if .Duration}}{{formatDuration .Duration}}{{end}}</td>
+</tr>
+<tr><td colspan="5">
+<details{{if eq .Status "failed"}} open{{end}}>
+<summary>Details</summary>
+{{if .Reason}}<p>Reason: {{.Reason}}</p>{{end}}
+{{if .Errors}}<ul>{{range .Errors}}<li class="error">{{.}}</li>{{end}}</ul>{{end}}
+{{if .Warnings}}<ul>{{range .Warnings}}<li class="warning">{{.}}</li>{{end}}</ul>{{end}}
+{{if .Fields}}<table class="fields">
+<tr><th>Field</th><th>Expected</th><th>Got</th></tr>
+{{range .Fields}}<tr{{if ne .Expected .Got}} class="error"{{end}}><td>{{.Name}}</td><td>{{.Expected}}</td><td>{{.Got}}</td></tr>
+{{end}}</table>{{end}}
+<p>Expected:</p>
+<pre>{{.Expected}}</pre>
+{{$events := .Events}}{{range $i, $event := $events}}<p>Log sample{{if gt (len $events) 1}} {{inc $i}}{{end}}:</p>
+<pre>{{$event}}</pre>
+{{end}}
+{{$responses := .Responses}}{{range $i, $response := $responses}}<p>Logtest response{{if gt (len $responses) 1}} {{inc $i}}{{end}}:</p>
+<pre>{{$response}}</pre>
+{{end}}
+</details>
+</td></tr>
+</tbody>
+{{end}}
+</table>
+{{end}}
+
+<script>
+function filterTests() {
+	var shown = {};
+	document.querySelectorAll(".filters input").forEach(function (input) {
+		shown[input.value] = input.checked;
+	});
+	document.querySelectorAll("tbody.test").forEach(function (test) {
+		test.classList.toggle("hidden", !shown[test.dataset.status]);
+	});
+}
+</script>
+</body>
+</html>
+`))
+
+// Number of tests with each status
+func countStatuses(report testReport) map[string]int {
+	counts := map[string]int{}
+	for _, status := range reportStatuses {
+		counts[status] = 0
+	}
+	for _, dir := range report.Dirs {
+		for _, test := range dir.Tests {
+			counts[test.Status]++
+		}
+	}
+	return counts
+}
+
+func writeHTMLReport(w io.Writer, report testReport) error {
+	return htmlReportTemplate.Execute(w, report)
+}
+
+// Writes a self-contained HTML report of the test results
+// to path
+func saveHTMLReport(testDirs []runner.TestDir, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := writeHTMLReport(file, buildTestReport(testDirs)); err != nil {
+		file.Close()
+		return err
+	}
+
+	// Buffered data is only known to be written once
+	// the file is closed
+	return file.Close()
+}
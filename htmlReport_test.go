@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+func Test_writeHTMLReport(t *testing.T) {
+	failed := wazuh.Response{Raw: map[string]interface{}{"data": map[string]interface{}{"output": map[string]interface{}{"rule": map[string]interface{}{"id": "5501"}}}}}
+	failed.Data.Output.Rule = wazuh.Rule{ID: "5501"}
+
+	testDirs := []runner.TestDir{{
+		TestDir: testdef.TestDir{
+			Path: "tests/sshd",
+			Tests: []testdef.LogTest{
+				{ID: "sshd/5710.json#1", TestDescription: "SSH invalid user", RuleID: "5710", RuleLevel: "5"},
+				{ID: "sshd/5715.json#1", TestDescription: "SSH login <success>", RuleID: "5715"},
+				{ID: "sshd/5716.json#1", TestDescription: "SSH auth failure", RuleID: "5716", Skip: "Flaky on 4.7"},
+			},
+			InvalidTests: 1,
+			LoadIssues: []testdef.LoadIssue{
+				{Path: "tests/sshd/5712.json", Errors: []string{"RuleID must be a number"}},
+				{Path: "tests/sshd/5713.json", Index: 1, Warnings: []string{"No log file found"}},
+			},
+		},
+		Results: []runner.Result{
+			{Passed: true, Duration: 40 * time.Millisecond, Events: []string{"Mar  5 13:49:34 host sshd: Invalid user"}},
+			{
+				Errors:   []string{"Expected rule ID 5715, got 5501"},
+				Events:   []string{"Mar  5 13:49:34 host sshd: Accepted password"},
+				Response: failed,
+			},
+			{Passed: true, Skipped: true},
+		},
+	}}
+
+	report := buildTestReport(testDirs)
+	if report.Summary.Total != 3 || report.Summary.Failed != 1 || report.Summary.Skipped != 1 {
+		t.Errorf("buildTestReport() summary = %+v", report.Summary)
+	}
+
+	var buf bytes.Buffer
+	if err := writeHTMLReport(&buf, report); err != nil {
+		t.Fatalf("writeHTMLReport() error = %v", err)
+	}
+	html := buf.String()
+
+	for _, want := range []string{
+		`<h2>tests/sshd</h2>`,
+		`data-status="failed"`,
+		`<details open>`,
+		`Expected rule ID 5715, got 5501`,
+		`&#34;id&#34;: &#34;5501&#34;`,
+		`Mar  5 13:49:34 host sshd: Accepted password`,
+		`SSH login &lt;success&gt;`,
+		`Reason: Flaky on 4.7`,
+		`<tr class="error"><td>RuleID</td><td>5715</td><td>5501</td></tr>`,
+		`<li class="error">tests/sshd/5712.json: Test #1: RuleID must be a number</li>`,
+		`<li class="warning">tests/sshd/5713.json: Test #2: No log file found</li>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("writeHTMLReport() output is missing %q", want)
+		}
+	}
+
+	// Only the failed test is expanded
+	if count := strings.Count(html, "<details open>"); count != 1 {
+		t.Errorf("writeHTMLReport() expanded %d tests, want 1", count)
+	}
+	if strings.Contains(html, "<link") || strings.Contains(html, "src=") {
+		t.Errorf("writeHTMLReport() output references external assets")
+	}
+}
@@ -0,0 +1,1110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// An experimental emulation of the Wazuh analysis engine that runs
+// tests without a manager. It loads decoder and rule XML files and
+// implements:
+//
+//   - Predecoding of syslog headers
+//   - Decoder selection with <program_name> and <prematch>, child
+//     decoders, <regex> with offsets, <order> and the JSON_Decoder
+//     plugin
+//   - Rule matching with <if_sid>, <if_group>, <if_level>, <match>,
+//     <regex>, <field>, <decoded_as>, <category> and the static field
+//     options (<srcip>, <user>, <program_name>, ...)
+//
+// Rules and decoders that use anything else are never evaluated.
+// When the engine reaches one of them a warning is returned with
+// the result instead of guessing how it would behave.
+type localEngine struct {
+	// Parent decoders with and without a <program_name>.
+	// Like Wazuh, decoders with a program name are tried
+	// first when the log has one.
+	pnDecoders   []*localDecoder
+	nopnDecoders []*localDecoder
+	numDecoders  int
+
+	rules []*localRule
+	roots []*localRule
+
+	// Problems found while loading the ruleset
+	loadWarnings []string
+
+	// How many times each rule fired on the shared session
+	fired *firedCounter
+}
+
+// Counts how many times each rule fired. Every logtest
+// session has its own counts like on the manager.
+type firedCounter struct {
+	lock  sync.Mutex
+	times map[string]int
+}
+
+func newFiredCounter() *firedCounter {
+	return &firedCounter{times: map[string]int{}}
+}
+
+// Record that the rule fired and return its new count
+func (counter *firedCounter) add(ruleID string) int {
+	counter.lock.Lock()
+	defer counter.lock.Unlock()
+	counter.times[ruleID]++
+	return counter.times[ruleID]
+}
+
+// A dedicated session of the local engine
+type localSession struct {
+	engine *localEngine
+	fired  *firedCounter
+}
+
+func (engine *localEngine) NewSession() wazuh.LogTestSession {
+	return &localSession{engine: engine, fired: newFiredCounter()}
+}
+
+func (session *localSession) SendLogTest(ctx context.Context, event string, logFormat string) (map[string]interface{}, []string, error) {
+	return session.engine.processLog(event, logFormat, session.fired)
+}
+
+func (session *localSession) Close() error {
+	return nil
+}
+
+type localDecoder struct {
+	Name       string
+	ParentName string
+	Type       string
+	UseOwnName bool
+	File       string
+	Line       int
+
+	programName    patternMatcher
+	prematch       patternMatcher
+	prematchOffset string
+	regex          patternMatcher
+	regexOffset    string
+	order          []string
+	jsonPlugin     bool
+	jsonOffset     string
+
+	children    []*localDecoder
+	unsupported []string
+}
+
+type localRule struct {
+	ID          string
+	Level       int
+	Description string
+	Groups      []string
+	Compliance  map[string][]string
+	Mitre       []string
+	Mail        bool
+	NoFullLog   bool
+	File        string
+	Line        int
+
+	overwrite bool
+	ifSids    []string
+	ifGroups  []string
+	ifLevel   string
+	decodedAs string
+	category  string
+
+	conditions []ruleCondition
+
+	children    []*localRule
+	unsupported []string
+}
+
+// A pattern that must match the value of an event field
+type ruleCondition struct {
+	field   string
+	matcher patternMatcher
+}
+
+// A log while it is being decoded and matched
+type localEvent struct {
+	fullLog  string
+	message  string
+	location string
+
+	predecoded  bool
+	timestamp   string
+	hostname    string
+	programName string
+
+	decoded     bool
+	decoderName string
+	parentName  string
+	decoderType string
+
+	// Decoded fields nested like the logtest output
+	// and flattened to dotted keys for matching
+	data   map[string]interface{}
+	fields map[string]string
+
+	warnings []string
+}
+
+// Fields of the event that are not decoded fields
+const (
+	fieldLog         = "$log"
+	fieldProgramName = "$program_name"
+	fieldHostname    = "$hostname"
+	fieldLocation    = "$location"
+	fieldUser        = "$user"
+)
+
+// Rule options that match a static field of the event with
+// the field they match. All default to the OS_Match syntax.
+var ruleStaticFields = map[string]string{
+	"user":         fieldUser,
+	"program_name": fieldProgramName,
+	"hostname":     fieldHostname,
+	"location":     fieldLocation,
+	"id":           "id",
+	"url":          "url",
+	"action":       "action",
+	"status":       "status",
+	"extra_data":   "extra_data",
+	"data":         "extra_data",
+	"protocol":     "protocol",
+	"system_name":  "system_name",
+	"srcport":      "srcport",
+	"dstport":      "dstport",
+}
+
+// Rule groups with these prefixes are reported as
+// compliance controls instead of groups
+var complianceGroupPrefixes = []struct {
+	Prefix string
+	Key    string
+}{
+	{"pci_dss_", "pci_dss"},
+	{"gdpr_", "gdpr"},
+	{"hipaa_", "hipaa"},
+	{"nist_800_53_", "nist_800_53"},
+	{"gpg13_", "gpg13"},
+	{"tsc_", "tsc"},
+}
+
+// Loads every decoder and rule file found in paths. Paths are
+// loaded in the order given and the files in a directory in name
+// order, so the default ruleset should come before custom rules.
+func newLocalEngine(paths []string) (*localEngine, error) {
+	engine := &localEngine{fired: newFiredCounter()}
+
+	rulesByID := map[string]*localRule{}
+	vars := map[string]string{}
+	var decoders []*localDecoder
+
+	for _, path := range paths {
+		xmlPaths, err := findXMLFiles(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, xmlPath := range xmlPaths {
+			content, err := os.ReadFile(xmlPath)
+			if err != nil {
+				return nil, err
+			}
+
+			nodes, err := parseXMLTree(content)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing %s: %s", xmlPath, err)
+			}
+
+			for _, node := range nodes {
+				switch node.Name {
+				case "decoder":
+					decoders = append(decoders, loadLocalDecoder(node, xmlPath))
+				case "var":
+					vars[node.Attrs["name"]] = node.Text
+				case "rule":
+					engine.addRule(loadLocalRule(node, nil, vars, xmlPath), rulesByID)
+				case "group":
+					groups := splitList(node.Attrs["name"], ",")
+					for _, child := range node.Children {
+						switch child.Name {
+						case "rule":
+							engine.addRule(loadLocalRule(child, groups, vars, xmlPath), rulesByID)
+						case "var":
+							vars[child.Attrs["name"]] = child.Text
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if len(decoders) == 0 && len(engine.rules) == 0 {
+		return nil, fmt.Errorf("no decoders or rules found in %s", strings.Join(paths, ", "))
+	}
+
+	engine.linkDecoders(decoders)
+	engine.linkRules(rulesByID)
+
+	return engine, nil
+}
+
+// Number of rules that use features the engine does
+// not support and are never evaluated
+func (engine *localEngine) numUnsupportedRules() int {
+	count := 0
+	for _, rule := range engine.rules {
+		if len(rule.unsupported) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+func printLocalEngineSummary(con console, engine *localEngine, verbosity int) {
+	con.white("Loaded " + strconv.Itoa(engine.numDecoders) + " decoders and " + strconv.Itoa(len(engine.rules)) + " rules into the local rule engine (experimental).")
+
+	if numUnsupported := engine.numUnsupportedRules(); numUnsupported > 0 {
+		con.yellow("WARNING: " + strconv.Itoa(numUnsupported) + " rules use features the local rule engine does not support and will not be evaluated.")
+		if verbosity > 1 {
+			for _, rule := range engine.rules {
+				if len(rule.unsupported) > 0 {
+					con.yellow("+ " + rule.File + ":" + strconv.Itoa(rule.Line) + ": rule " + rule.ID + ": " + strings.Join(rule.unsupported, ", "))
+				}
+			}
+		}
+	}
+
+	if len(engine.loadWarnings) > 0 {
+		con.yellow("WARNING: " + strconv.Itoa(len(engine.loadWarnings)) + " problems found while loading the ruleset.")
+		if verbosity > 0 {
+			for _, warning := range engine.loadWarnings {
+				con.yellow("+ " + warning)
+			}
+		}
+	}
+
+	con.printf("\n")
+}
+
+func loadLocalDecoder(node *xmlNode, path string) *localDecoder {
+	dec := &localDecoder{Name: node.Attrs["name"], File: path, Line: node.Line}
+
+	for _, child := range node.Children {
+		var err error
+		switch child.Name {
+		case "parent":
+			dec.ParentName = child.Text
+		case "type":
+			dec.Type = child.Text
+		case "use_own_name":
+			dec.UseOwnName = child.Text == "true"
+		case "program_name":
+			dec.programName, err = compileNodePattern(child, "osmatch")
+		case "prematch":
+			dec.prematch, err = compileNodePattern(child, "osregex")
+			dec.prematchOffset = child.Attrs["offset"]
+		case "regex":
+			dec.regex, err = compileNodePattern(child, "osregex")
+			dec.regexOffset = child.Attrs["offset"]
+		case "order":
+			dec.order = splitList(child.Text, ",")
+		case "plugin_decoder":
+			if child.Text != "JSON_Decoder" {
+				dec.unsupported = append(dec.unsupported, "<plugin_decoder>"+child.Text)
+			}
+			dec.jsonPlugin = true
+			dec.jsonOffset = child.Attrs["offset"]
+		case "fts", "ftscomment", "json_null_field", "json_array_structure":
+			// Only affect alerting or rarely used JSON options
+		default:
+			dec.unsupported = append(dec.unsupported, "<"+child.Name+">")
+		}
+
+		if err != nil {
+			dec.unsupported = append(dec.unsupported, err.Error())
+		}
+	}
+
+	return dec
+}
+
+func loadLocalRule(node *xmlNode, groups []string, vars map[string]string, path string) *localRule {
+	rule := &localRule{
+		ID:         node.Attrs["id"],
+		Groups:     append([]string{}, groups...),
+		Compliance: map[string][]string{},
+		File:       path,
+		Line:       node.Line,
+		overwrite:  node.Attrs["overwrite"] == "yes",
+	}
+
+	// Sorted so unsupported features are listed in
+	// the same order every run
+	attrNames := make([]string, 0, len(node.Attrs))
+	for name := range node.Attrs {
+		attrNames = append(attrNames, name)
+	}
+	sort.Strings(attrNames)
+
+	for _, name := range attrNames {
+		value := node.Attrs[name]
+		switch name {
+		case "id", "overwrite", "noalert":
+		case "level":
+			level, err := strconv.Atoi(value)
+			if err != nil {
+				rule.unsupported = append(rule.unsupported, "level "+value)
+			}
+			rule.Level = level
+		default:
+			rule.unsupported = append(rule.unsupported, name+"=\""+value+"\"")
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, child := range node.Children {
+		text := expandVars(child.Text, vars)
+
+		var err error
+		switch child.Name {
+		case "description":
+			rule.Description = text
+		case "group":
+			rule.Groups = append(rule.Groups, splitList(text, ",")...)
+		case "mitre":
+			for _, id := range child.Children {
+				if id.Name == "id" {
+					rule.Mitre = append(rule.Mitre, id.Text)
+				}
+			}
+		case "options":
+			for _, option := range splitList(text, ",") {
+				switch option {
+				case "alert_by_email":
+					rule.Mail = true
+				case "no_full_log":
+					rule.NoFullLog = true
+				}
+			}
+		case "info":
+		case "if_sid":
+			rule.ifSids = append(rule.ifSids, splitList(strings.ReplaceAll(text, " ", ","), ",")...)
+		case "if_group":
+			rule.ifGroups = append(rule.ifGroups, splitList(strings.ReplaceAll(text, "|", ","), ",")...)
+		case "if_level":
+			rule.ifLevel = text
+		case "if_matched_sid":
+			// Still a child of the rule so the warning is
+			// only returned when the parent matches
+			rule.ifSids = append(rule.ifSids, splitList(text, ",")...)
+			rule.unsupported = append(rule.unsupported, "<"+child.Name+">")
+		case "if_matched_group":
+			rule.ifGroups = append(rule.ifGroups, splitList(text, ",")...)
+			rule.unsupported = append(rule.unsupported, "<"+child.Name+">")
+		case "decoded_as":
+			rule.decodedAs = text
+		case "category":
+			rule.category = text
+		case "match", "regex", "field", "srcip", "dstip":
+			key := child.Name + ":" + child.Attrs["name"]
+			if seen[key] {
+				rule.unsupported = append(rule.unsupported, "multiple <"+child.Name+">")
+				break
+			}
+			seen[key] = true
+
+			field := fieldLog
+			patternType := "osregex"
+			switch child.Name {
+			case "match":
+				patternType = "osmatch"
+			case "field":
+				field = child.Attrs["name"]
+			case "srcip", "dstip":
+				field = child.Name
+			}
+
+			var matcher patternMatcher
+			if child.Name == "srcip" || child.Name == "dstip" {
+				matcher, err = compileIPMatch(text)
+			} else {
+				matcher, err = compilePattern(text, nodePatternType(child, patternType), child.Attrs["negate"] == "yes")
+			}
+			if err == nil {
+				rule.conditions = append(rule.conditions, ruleCondition{field, matcher})
+			}
+		default:
+			field, isStatic := ruleStaticFields[child.Name]
+			if !isStatic {
+				rule.unsupported = append(rule.unsupported, "<"+child.Name+">")
+				break
+			}
+
+			var matcher patternMatcher
+			matcher, err = compilePattern(text, nodePatternType(child, "osmatch"), child.Attrs["negate"] == "yes")
+			if err == nil {
+				rule.conditions = append(rule.conditions, ruleCondition{field, matcher})
+			}
+		}
+
+		if err != nil {
+			rule.unsupported = append(rule.unsupported, err.Error())
+		}
+	}
+
+	// Compliance controls are kept out of the groups
+	groups = rule.Groups
+	rule.Groups = nil
+	for _, group := range groups {
+		isControl := false
+		for _, prefix := range complianceGroupPrefixes {
+			if strings.HasPrefix(group, prefix.Prefix) {
+				rule.Compliance[prefix.Key] = append(rule.Compliance[prefix.Key], strings.TrimPrefix(group, prefix.Prefix))
+				isControl = true
+				break
+			}
+		}
+		if !isControl {
+			rule.Groups = append(rule.Groups, group)
+		}
+	}
+
+	return rule
+}
+
+// Adds a loaded rule. Overwrites replace the original rule
+// in place but keep its position in the rule tree.
+func (engine *localEngine) addRule(rule *localRule, rulesByID map[string]*localRule) {
+	location := rule.File + ":" + strconv.Itoa(rule.Line)
+	original, exists := rulesByID[rule.ID]
+
+	if rule.overwrite {
+		if !exists {
+			engine.loadWarnings = append(engine.loadWarnings, location+": rule "+rule.ID+" overwrites a rule that does not exist")
+			return
+		}
+
+		rule.ifSids = original.ifSids
+		rule.ifGroups = original.ifGroups
+		rule.ifLevel = original.ifLevel
+		*original = *rule
+		return
+	}
+
+	if exists {
+		engine.loadWarnings = append(engine.loadWarnings, location+": duplicate rule ID "+rule.ID+" is ignored")
+		return
+	}
+
+	rulesByID[rule.ID] = rule
+	engine.rules = append(engine.rules, rule)
+}
+
+// Attaches child decoders to their parent decoder
+func (engine *localEngine) linkDecoders(decoders []*localDecoder) {
+	parents := map[string]*localDecoder{}
+	for _, dec := range decoders {
+		if dec.ParentName != "" {
+			continue
+		}
+
+		if _, exists := parents[dec.Name]; !exists {
+			parents[dec.Name] = dec
+		}
+		if dec.programName != nil {
+			engine.pnDecoders = append(engine.pnDecoders, dec)
+		} else {
+			engine.nopnDecoders = append(engine.nopnDecoders, dec)
+		}
+		engine.numDecoders++
+	}
+
+	for _, dec := range decoders {
+		if dec.ParentName == "" {
+			continue
+		}
+
+		parent, exists := parents[dec.ParentName]
+		if !exists {
+			engine.loadWarnings = append(engine.loadWarnings, dec.File+":"+strconv.Itoa(dec.Line)+": decoder "+dec.Name+" has unknown parent "+dec.ParentName)
+			continue
+		}
+		parent.children = append(parent.children, dec)
+		engine.numDecoders++
+	}
+}
+
+// Builds the rule tree. Like Wazuh, only the template rules
+// (IDs below 10, e.g. rule 1 for all syslog events) are at the
+// top level and other rules without a parent are children of
+// the templates with the same category. Without templates
+// those rules are at the top level instead. Children are
+// checked in the order they were loaded.
+func (engine *localEngine) linkRules(rulesByID map[string]*localRule) {
+	var templates []*localRule
+	for _, rule := range engine.rules {
+		if id, err := strconv.Atoi(rule.ID); err == nil && id < 10 && !rule.hasParent() {
+			templates = append(templates, rule)
+		}
+	}
+	engine.roots = append(engine.roots, templates...)
+
+	for _, rule := range engine.rules {
+		if containsRule(templates, rule) {
+			continue
+		}
+
+		if !rule.hasParent() {
+			attached := false
+			for _, template := range templates {
+				if template.getCategory() == rule.getCategory() {
+					template.children = append(template.children, rule)
+					attached = true
+				}
+			}
+			if !attached {
+				engine.roots = append(engine.roots, rule)
+			}
+			continue
+		}
+
+		location := rule.File + ":" + strconv.Itoa(rule.Line)
+		for _, sid := range rule.ifSids {
+			parent, exists := rulesByID[sid]
+			if !exists {
+				engine.loadWarnings = append(engine.loadWarnings, location+": rule "+rule.ID+" depends on rule "+sid+" which does not exist")
+				continue
+			}
+			parent.children = append(parent.children, rule)
+		}
+
+		for _, parent := range engine.rules {
+			if parent == rule {
+				continue
+			}
+			if rule.ifLevel != "" && strconv.Itoa(parent.Level) == rule.ifLevel {
+				parent.children = append(parent.children, rule)
+				continue
+			}
+			for _, group := range rule.ifGroups {
+				if containsString(parent.Groups, group) {
+					parent.children = append(parent.children, rule)
+					break
+				}
+			}
+		}
+	}
+}
+
+// Processes a log like the logtest API and returns a result
+// in the same format. Warnings are returned for every
+// unsupported rule or decoder that was reached. Logs are
+// processed in place so ctx is not needed.
+func (engine *localEngine) SendLogTest(ctx context.Context, event string, logFormat string) (map[string]interface{}, []string, error) {
+	return engine.processLog(event, logFormat, engine.fired)
+}
+
+func (engine *localEngine) processLog(event string, logFormat string, fired *firedCounter) (map[string]interface{}, []string, error) {
+	predecode, supported := localLogFormats[logFormat]
+	if !supported {
+		return nil, nil, fmt.Errorf("log format %s is not supported by the local backend", logFormat)
+	}
+
+	ev := &localEvent{
+		fullLog:  strings.TrimRight(event, "\r\n"),
+		location: "WazuhTestRunner",
+		data:     map[string]interface{}{},
+		fields:   map[string]string{},
+	}
+
+	ev.message = ev.fullLog
+	if predecode {
+		ev.predecode()
+	}
+	engine.decode(ev)
+
+	var matched *localRule
+	for _, root := range engine.roots {
+		if matched = engine.checkRule(root, ev, 0); matched != nil {
+			break
+		}
+	}
+
+	output := map[string]interface{}{
+		"timestamp": time.Now().Format("2006-01-02T15:04:05.000-0700"),
+		"location":  ev.location,
+		"full_log":  ev.fullLog,
+	}
+
+	if ev.predecoded {
+		output["predecoder"] = map[string]interface{}{
+			"timestamp":    ev.timestamp,
+			"hostname":     ev.hostname,
+			"program_name": ev.programName,
+		}
+	}
+
+	if ev.decoded {
+		decoder := map[string]interface{}{"name": ev.decoderName}
+		if ev.parentName != "" {
+			decoder["parent"] = ev.parentName
+		}
+		output["decoder"] = decoder
+	}
+
+	if len(ev.data) > 0 {
+		output["data"] = ev.data
+	}
+
+	if matched != nil {
+		output["rule"] = engine.ruleOutput(matched, ev, fired.add(matched.ID))
+		if matched.NoFullLog {
+			delete(output, "full_log")
+		}
+	}
+
+	messages := []interface{}{}
+	for _, warning := range ev.warnings {
+		messages = append(messages, "WARNING: "+warning)
+	}
+
+	result := map[string]interface{}{
+		"error": 0,
+		"data": map[string]interface{}{
+			"output":   output,
+			"alert":    matched != nil && matched.Level > 0,
+			"codemsg":  1,
+			"messages": messages,
+		},
+	}
+
+	// Return the same types as a decoded API response
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, err
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(jsonBytes, &decoded); err != nil {
+		return nil, nil, err
+	}
+
+	return decoded, ev.warnings, nil
+}
+
+func (engine *localEngine) ruleOutput(rule *localRule, ev *localEvent, firedTimes int) map[string]interface{} {
+	output := map[string]interface{}{
+		"id":          rule.ID,
+		"level":       rule.Level,
+		"description": ev.expandFields(rule.Description),
+		"groups":      rule.Groups,
+		"firedtimes":  firedTimes,
+		"mail":        rule.Mail,
+	}
+
+	if len(rule.Mitre) > 0 {
+		output["mitre"] = map[string]interface{}{"id": rule.Mitre}
+	}
+
+	for key, controls := range rule.Compliance {
+		output[key] = controls
+	}
+
+	return output
+}
+
+// The log formats the emulator handles and whether their
+// events have a syslog header to predecode. JSON events are
+// sent by logcollector as is. Formats that logcollector
+// rewrites, such as command output and multi-line logs, are
+// not emulated.
+var localLogFormats = map[string]bool{
+	"syslog":       true,
+	"json":         false,
+	"eventchannel": false,
+}
+
+// Syslog headers understood by the predecoder. Each captures
+// the timestamp, hostname, program name and message.
+var syslogHeaders = []*regexp.Regexp{
+	regexp.MustCompile(`(?s)^([A-Z][a-z]{2} [ 0-3]\d \d{2}:\d{2}:\d{2}) (\S+) ([^\s\[:]+)(?:\[\d+\])?: ?(.*)$`),
+	regexp.MustCompile(`(?s)^([A-Z][a-z]{2} [ 0-3]\d \d{4} \d{2}:\d{2}:\d{2}) (\S+) ([^\s\[:]+)(?:\[\d+\])?: ?(.*)$`),
+	regexp.MustCompile(`(?s)^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?) (\S+) ([^\s\[:]+)(?:\[\d+\])?: ?(.*)$`),
+}
+
+// Splits the syslog header from the message
+func (ev *localEvent) predecode() {
+	for _, header := range syslogHeaders {
+		match := header.FindStringSubmatch(ev.fullLog)
+		if match == nil {
+			continue
+		}
+
+		ev.predecoded = true
+		ev.timestamp = match[1]
+		ev.hostname = match[2]
+		ev.programName = match[3]
+		ev.message = match[4]
+		return
+	}
+}
+
+// Finds the first parent decoder that matches and then the
+// first of its children that matches. Children sharing a name
+// (sibling decoders) are all applied.
+func (engine *localEngine) decode(ev *localEvent) {
+	candidates := engine.nopnDecoders
+	if ev.programName != "" {
+		candidates = append(append([]*localDecoder{}, engine.pnDecoders...), engine.nopnDecoders...)
+	}
+
+	for _, parent := range candidates {
+		if parent.programName == nil && parent.prematch == nil && len(parent.unsupported) == 0 {
+			continue
+		}
+		if parent.programName != nil && parent.programName.find(ev.programName) == nil {
+			continue
+		}
+
+		prematchEnd := 0
+		if parent.prematch != nil {
+			loc := parent.prematch.find(ev.message)
+			if loc == nil {
+				continue
+			}
+			prematchEnd = loc[1]
+		}
+
+		if len(parent.unsupported) > 0 {
+			ev.warn("Decoder " + parent.Name + " was not evaluated, it uses unsupported features: " + strings.Join(parent.unsupported, ", "))
+			continue
+		}
+
+		ev.decoded = true
+		ev.decoderName = parent.Name
+		ev.decoderType = parent.Type
+		parent.extract(ev, prematchEnd, prematchEnd, 0)
+
+		var selected *localDecoder
+		regexEnd := 0
+		for _, child := range parent.children {
+			if selected != nil && child.Name != selected.Name {
+				break
+			}
+
+			childPrematchEnd := 0
+			if child.prematch != nil {
+				offset := 0
+				if child.prematchOffset == "after_parent" {
+					offset = prematchEnd
+				}
+				loc := child.prematch.find(ev.message[offset:])
+				if loc == nil {
+					continue
+				}
+				childPrematchEnd = offset + loc[1]
+			}
+
+			if len(child.unsupported) > 0 {
+				ev.warn("Decoder " + child.Name + " was not evaluated, it uses unsupported features: " + strings.Join(child.unsupported, ", "))
+				continue
+			}
+
+			end, matched := child.extract(ev, prematchEnd, childPrematchEnd, regexEnd)
+			if !matched {
+				continue
+			}
+			regexEnd = end
+
+			if selected == nil {
+				selected = child
+				ev.parentName = parent.Name
+				if child.UseOwnName {
+					ev.decoderName = child.Name
+				}
+				if child.Type != "" {
+					ev.decoderType = child.Type
+				}
+			}
+		}
+
+		return
+	}
+}
+
+// Applies the regex and JSON plugin of a decoder. Returns where
+// the regex match ended and false if the regex did not match.
+func (dec *localDecoder) extract(ev *localEvent, parentEnd int, prematchEnd int, regexEnd int) (int, bool) {
+	getOffset := func(offset string) int {
+		switch offset {
+		case "after_parent":
+			return parentEnd
+		case "after_prematch":
+			return prematchEnd
+		case "after_regex":
+			return regexEnd
+		}
+		return 0
+	}
+
+	end := regexEnd
+	if dec.regex != nil {
+		offset := getOffset(dec.regexOffset)
+		input := ev.message[offset:]
+
+		loc := dec.regex.find(input)
+		if loc == nil {
+			return end, false
+		}
+		end = offset + loc[1]
+
+		for i, name := range dec.order {
+			group := (i + 1) * 2
+			if group+1 >= len(loc) || loc[group] < 0 {
+				break
+			}
+			ev.setField(name, input[loc[group]:loc[group+1]])
+		}
+	}
+
+	if dec.jsonPlugin {
+		var parsed interface{}
+		input := ev.message[getOffset(dec.jsonOffset):]
+		if err := json.Unmarshal([]byte(input), &parsed); err == nil {
+			ev.setJSONFields("", parsed)
+		}
+	}
+
+	return end, true
+}
+
+// Checks a rule and then its children, returning the most
+// specific rule that matched
+func (engine *localEngine) checkRule(rule *localRule, ev *localEvent, depth int) *localRule {
+	// Guard against if_sid loops
+	if depth > 64 || !rule.matches(ev) {
+		return nil
+	}
+
+	if len(rule.unsupported) > 0 {
+		ev.warn("Rule " + rule.ID + " was not evaluated, it uses unsupported features: " + strings.Join(rule.unsupported, ", "))
+		return nil
+	}
+
+	for _, child := range rule.children {
+		if matched := engine.checkRule(child, ev, depth+1); matched != nil {
+			return matched
+		}
+	}
+
+	return rule
+}
+
+func (rule *localRule) hasParent() bool {
+	return len(rule.ifSids) > 0 || len(rule.ifGroups) > 0 || rule.ifLevel != ""
+}
+
+// Rules without a <category> are syslog rules
+func (rule *localRule) getCategory() string {
+	if rule.category == "" {
+		return "syslog"
+	}
+	return rule.category
+}
+
+func (rule *localRule) matches(ev *localEvent) bool {
+	if rule.decodedAs != "" && (!ev.decoded || ev.decoderName != rule.decodedAs) {
+		return false
+	}
+
+	if rule.category != "" {
+		decoderType := ev.decoderType
+		if decoderType == "" {
+			decoderType = "syslog"
+		}
+		if decoderType != rule.category {
+			return false
+		}
+	}
+
+	for _, cond := range rule.conditions {
+		value, ok := ev.value(cond.field)
+		if !ok || cond.matcher.find(value) == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (ev *localEvent) value(field string) (string, bool) {
+	switch field {
+	case fieldLog:
+		return ev.message, true
+	case fieldProgramName:
+		return ev.programName, ev.programName != ""
+	case fieldHostname:
+		return ev.hostname, ev.hostname != ""
+	case fieldLocation:
+		return ev.location, true
+	case fieldUser:
+		if user, ok := ev.fields["dstuser"]; ok {
+			return user, true
+		}
+		user, ok := ev.fields["srcuser"]
+		return user, ok
+	}
+
+	value, ok := ev.fields[field]
+	return value, ok
+}
+
+// Stores a decoded field. Fields with dots are nested
+// in the output like the logtest API does.
+func (ev *localEvent) setField(name string, value string) {
+	// The user order field is reported as dstuser
+	if name == "user" {
+		name = "dstuser"
+	}
+
+	ev.fields[name] = value
+	setNestedValue(ev.data, name, value)
+}
+
+// Stores every value of a decoded JSON object. Values are
+// stored as strings like the Wazuh JSON decoder does.
+func (ev *localEvent) setJSONFields(prefix string, val interface{}) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			ev.setJSONFields(key, child)
+		}
+	case []interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, elem := range v {
+			values = append(values, testdef.FormatJSONValue(elem))
+		}
+		ev.fields[prefix] = testdef.FormatJSONValue(values)
+		setNestedValue(ev.data, prefix, values)
+	case nil:
+	default:
+		ev.setField(prefix, testdef.FormatJSONValue(v))
+	}
+}
+
+func setNestedValue(data map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	node := data
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := node[part].(map[string]interface{})
+		if !ok {
+			// Keep the dotted key rather than
+			// replacing an existing value
+			if _, exists := node[part]; exists {
+				data[key] = value
+				return
+			}
+			child = map[string]interface{}{}
+			node[part] = child
+		}
+		node = child
+	}
+	node[parts[len(parts)-1]] = value
+}
+
+var descriptionFieldRegex = regexp.MustCompile(`\$\(([^)\s]+)\)`)
+
+// Replaces $(field) in rule descriptions
+func (ev *localEvent) expandFields(text string) string {
+	return descriptionFieldRegex.ReplaceAllStringFunc(text, func(match string) string {
+		name := descriptionFieldRegex.FindStringSubmatch(match)[1]
+		if value, ok := ev.value(name); ok {
+			return value
+		}
+		return ""
+	})
+}
+
+func (ev *localEvent) warn(warning string) {
+	if !containsString(ev.warnings, warning) {
+		ev.warnings = append(ev.warnings, warning)
+	}
+}
+
+// Replaces $NAME with the value of a <var>
+func expandVars(text string, vars map[string]string) string {
+	if !strings.Contains(text, "$") {
+		return text
+	}
+
+	// Longest names first so $VAR does not replace
+	// the start of $VARIABLE
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	for _, name := range names {
+		text = strings.ReplaceAll(text, "$"+name, vars[name])
+	}
+	return text
+}
+
+func compileNodePattern(node *xmlNode, defaultType string) (patternMatcher, error) {
+	return compilePattern(node.Text, nodePatternType(node, defaultType), node.Attrs["negate"] == "yes")
+}
+
+func nodePatternType(node *xmlNode, defaultType string) string {
+	if patternType, ok := node.Attrs["type"]; ok {
+		return patternType
+	}
+	return defaultType
+}
+
+// Splits a list and drops empty entries
+func splitList(text string, sep string) []string {
+	var values []string
+	for _, value := range strings.Split(text, sep) {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func containsRule(rules []*localRule, rule *localRule) bool {
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/alexchristy/WazuhTest/testdef"
+)
+
+func Test_compileOSRegex(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		value   string
+		want    []string
+	}{
+		{name: "Valid classes and groups", pattern: `^User '(\w+)' logged from '(\d+.\d+.\d+.\d+)'`, value: "User 'admin' logged from '10.0.0.4'", want: []string{"admin", "10.0.0.4"}},
+		{name: "Valid lazy quantifier before literal", pattern: `^(\.+) from (\S+)`, value: "bob smith from 1.2.3.4 from 5.6.7.8", want: []string{"bob smith", "1.2.3.4"}},
+		{name: "Valid greedy quantifier at end", pattern: `port (\d+)`, value: "port 59528", want: []string{"59528"}},
+		{name: "Valid case insensitive", pattern: `invalid user (\S+)`, value: "Invalid User root", want: []string{"root"}},
+		{name: "Valid alternation with anchors", pattern: `^Accepted|^Failed`, value: "Failed password", want: []string{}},
+		{name: "Valid literal dot", pattern: `a.b`, value: "a.b", want: []string{}},
+
+		{name: "Invalid literal dot", pattern: `a.b`, value: "axb", want: nil},
+		{name: "Invalid start anchor", pattern: `^Failed`, value: "Password Failed", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			matcher, err := compileOSRegex(tt.pattern)
+			if err != nil {
+				t.Fatalf("compileOSRegex() error = %v", err)
+			}
+
+			loc := matcher.find(tt.value)
+			if tt.want == nil {
+				if loc != nil {
+					t.Errorf("find() matched %q, want no match", tt.value)
+				}
+				return
+			}
+			if loc == nil {
+				t.Fatalf("find() did not match %q", tt.value)
+			}
+
+			got := []string{}
+			for i := 2; i+1 < len(loc); i += 2 {
+				got = append(got, tt.value[loc[i]:loc[i+1]])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("find() groups = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_compileOSMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		value   string
+		want    bool
+	}{
+		{name: "Valid substring", pattern: "invalid user", value: "Invalid user bob", want: true},
+		{name: "Valid alternation", pattern: "illegal user|invalid user", value: "Illegal user bob", want: true},
+		{name: "Valid start anchor", pattern: "^sshd", value: "sshd", want: true},
+		{name: "Valid end anchor", pattern: "mode$", value: "promiscuous mode", want: true},
+		{name: "Valid negation", pattern: "!root", value: "bob", want: true},
+
+		{name: "Invalid start anchor", pattern: "^sshd", value: "not sshd", want: false},
+		{name: "Invalid exact match", pattern: "^sshd$", value: "sshd2", want: false},
+		{name: "Invalid negation", pattern: "!root", value: "root", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			matcher, err := compileOSMatch(tt.pattern)
+			if err != nil {
+				t.Fatalf("compileOSMatch() error = %v", err)
+			}
+			if got := matcher.find(tt.value) != nil; got != tt.want {
+				t.Errorf("find() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func writeTestRuleset(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func Test_localEngine(t *testing.T) {
+	dir := writeTestRuleset(t, map[string]string{
+		"0010-decoders.xml": `<decoder name="sshd">
+  <program_name>^sshd</program_name>
+</decoder>
+
+<decoder name="sshd-invalid">
+  <parent>sshd</parent>
+  <prematch>^Invalid user</prematch>
+  <regex offset="after_prematch">^ (\S+) from (\S+) port (\d+)</regex>
+  <order>srcuser, srcip, srcport</order>
+</decoder>
+
+<decoder name="json">
+  <prematch>^{\s*"</prematch>
+  <plugin_decoder>JSON_Decoder</plugin_decoder>
+</decoder>
+`,
+		"0020-rules.xml": `<group name="syslog,">
+  <rule id="1" level="0" noalert="1">
+    <category>syslog</category>
+    <description>Generic template for all syslog rules.</description>
+  </rule>
+</group>
+
+<group name="syslog,sshd,">
+  <rule id="5700" level="0" noalert="1">
+    <decoded_as>sshd</decoded_as>
+    <description>SSHD messages grouped.</description>
+  </rule>
+
+  <rule id="5710" level="5">
+    <if_sid>5700</if_sid>
+    <match>illegal user|invalid user</match>
+    <description>sshd: Attempt to login using a non-existent user $(srcuser)</description>
+    <mitre>
+      <id>T1110.001</id>
+    </mitre>
+    <group>invalid_login,pci_dss_10.2.4,</group>
+  </rule>
+
+  <rule id="5712" level="10" frequency="8" timeframe="120">
+    <if_matched_sid>5710</if_matched_sid>
+    <description>sshd: brute force trying to get access to the system.</description>
+  </rule>
+
+  <rule id="100001" level="12">
+    <if_sid>5710</if_sid>
+    <srcip>10.0.0.0/8</srcip>
+    <description>Non-existent user from the internal network.</description>
+  </rule>
+</group>
+
+<group name="json,">
+  <rule id="100010" level="3">
+    <decoded_as>json</decoded_as>
+    <field name="win.system.eventID">^4625$</field>
+    <description>Logon failure.</description>
+  </rule>
+</group>
+`,
+	})
+
+	engine, err := newLocalEngine([]string{dir})
+	if err != nil {
+		t.Fatalf("newLocalEngine() error = %v", err)
+	}
+
+	if engine.numUnsupportedRules() != 1 {
+		t.Errorf("numUnsupportedRules() = %d, want 1", engine.numUnsupportedRules())
+	}
+
+	tests := []struct {
+		name         string
+		event        string
+		format       string
+		wantErr      string
+		wantRule     string
+		wantLevel    int
+		wantWarnings int
+		want         map[string]string
+	}{
+		{
+			name:         "Child decoder and rule with unsupported sibling",
+			event:        "Mar  5 13:49:34 ip-10-0-0-10 sshd[1602]: Invalid user bob from 192.168.1.4 port 59528",
+			wantRule:     "5710",
+			wantLevel:    5,
+			wantWarnings: 1,
+			want: map[string]string{
+				"data.output.predecoder.program_name": "sshd",
+				"data.output.predecoder.hostname":     "ip-10-0-0-10",
+				"data.output.decoder.name":            "sshd",
+				"data.output.decoder.parent":          "sshd",
+				"data.output.data.srcuser":            "bob",
+				"data.output.data.srcport":            "59528",
+				"data.output.rule.description":        "sshd: Attempt to login using a non-existent user bob",
+				"data.output.rule.mitre.id.0":         "T1110.001",
+				"data.output.rule.groups.2":           "invalid_login",
+				"data.output.rule.pci_dss.0":          "10.2.4",
+			},
+		},
+		{
+			name:         "Static field condition",
+			event:        "Mar  5 13:49:34 ip-10-0-0-10 sshd[1602]: Invalid user bob from 10.0.0.4 port 59528",
+			wantRule:     "100001",
+			wantLevel:    12,
+			wantWarnings: 1,
+			want:         map[string]string{"data.output.data.srcip": "10.0.0.4"},
+		},
+		{
+			name:         "JSON decoder and nested field",
+			event:        `{"win": {"system": {"eventID": 4625}, "eventdata": {"targetUserName": "bob"}}}`,
+			wantRule:     "100010",
+			wantLevel:    3,
+			wantWarnings: 0,
+			want: map[string]string{
+				"data.output.decoder.name":                      "json",
+				"data.output.data.win.eventdata.targetUserName": "bob",
+				"data.output.data.win.system.eventID":           "4625",
+			},
+		},
+		{
+			name:         "JSON format",
+			event:        `{"win": {"system": {"eventID": 4625}, "eventdata": {"targetUserName": "bob"}}}`,
+			format:       "eventchannel",
+			wantRule:     "100010",
+			wantLevel:    3,
+			wantWarnings: 0,
+			want:         map[string]string{"data.output.decoder.name": "json"},
+		},
+		{
+			name:         "JSON format has no syslog header",
+			event:        "Mar  5 13:49:34 ip-10-0-0-10 sshd[1602]: Invalid user bob from 192.168.1.4 port 59528",
+			format:       "json",
+			wantRule:     "1",
+			wantLevel:    0,
+			wantWarnings: 0,
+			want:         map[string]string{},
+		},
+		{
+			name:    "Unsupported command format",
+			event:   "ossec: output: 'df -P': /dev/sda1 100%",
+			format:  "command",
+			wantErr: "log format command is not supported by the local backend",
+		},
+		{
+			name:    "Unsupported multi-line format",
+			event:   "line one\nline two",
+			format:  "multi-line:2",
+			wantErr: "log format multi-line:2 is not supported by the local backend",
+		},
+		{
+			name:         "No decoder falls back to the template rule",
+			event:        "random message",
+			wantRule:     "1",
+			wantLevel:    0,
+			wantWarnings: 0,
+			want:         map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			format := tt.format
+			if format == "" {
+				format = "syslog"
+			}
+
+			result, warnings, err := engine.SendLogTest(context.Background(), tt.event, format)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("sendLogTest() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sendLogTest() error = %v", err)
+			}
+
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("sendLogTest() warnings = %v, want %d", warnings, tt.wantWarnings)
+			}
+
+			if got, _ := testdef.LookupJSONPath(result, "data.output.rule.id"); got != tt.wantRule {
+				t.Errorf("rule.id = %v, want %v", got, tt.wantRule)
+			}
+			if got, _ := testdef.LookupJSONPath(result, "data.output.rule.level"); got != float64(tt.wantLevel) {
+				t.Errorf("rule.level = %v, want %v", got, tt.wantLevel)
+			}
+
+			for path, want := range tt.want {
+				got, found := testdef.LookupJSONPath(result, path)
+				if !found || !testdef.JSONValueMatches(want, got) {
+					t.Errorf("%s = %v, want %v", path, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// A compiled Wazuh pattern. find returns the submatch index
+// pairs like regexp.FindStringSubmatchIndex, or nil if the
+// pattern does not match. The first pair is the whole match.
+type patternMatcher interface {
+	find(value string) []int
+}
+
+// Compiles a pattern of the given type ("osregex", "osmatch"
+// or "pcre2"). negate inverts the result as with negate="yes".
+func compilePattern(pattern string, patternType string, negate bool) (patternMatcher, error) {
+	var matcher patternMatcher
+	var err error
+
+	switch strings.ToLower(patternType) {
+	case "osregex":
+		matcher, err = compileOSRegex(pattern)
+	case "osmatch":
+		matcher, err = compileOSMatch(pattern)
+	case "pcre2":
+		var re *regexp.Regexp
+		re, err = regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("PCRE2 pattern %q is not supported: %s", pattern, err)
+		}
+		matcher = regexMatcher{re}
+	default:
+		return nil, fmt.Errorf("pattern type %s is not supported", patternType)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	if negate {
+		matcher = negatedMatcher{matcher}
+	}
+
+	return matcher, nil
+}
+
+type regexMatcher struct {
+	re *regexp.Regexp
+}
+
+func (m regexMatcher) find(value string) []int {
+	return m.re.FindStringSubmatchIndex(value)
+}
+
+type negatedMatcher struct {
+	matcher patternMatcher
+}
+
+func (m negatedMatcher) find(value string) []int {
+	if m.matcher.find(value) != nil {
+		return nil
+	}
+	return []int{0, 0}
+}
+
+// Character classes of the Wazuh regex syntax as Go regexp classes
+var osRegexClasses = map[byte]string{
+	'w': `[\w@\-]`,
+	'W': `[^\w@\-]`,
+	'd': `[0-9]`,
+	'D': `[^0-9]`,
+	's': `[ ]`,
+	'S': `[^ ]`,
+	'p': `[()*+,\-.:;<=>?\[\]!"'#$%&|{}]`,
+	't': `\t`,
+	'.': `(?s:.)`,
+}
+
+// Translates the Wazuh regex (OS_Regex) syntax to a Go regexp.
+// Both are case insensitive. The + and * quantifiers only apply
+// to character classes and stop as soon as the rest of the
+// pattern matches, so they are lazy unless nothing follows them.
+func compileOSRegex(pattern string) (patternMatcher, error) {
+	if err := validateOSRegex(pattern); err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %s", pattern, err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("(?i)")
+
+	atStart := true
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+
+		switch {
+		case c == '\\':
+			i++
+			class, isClass := osRegexClasses[pattern[i]]
+			if !isClass {
+				sb.WriteString(regexp.QuoteMeta(string(pattern[i])))
+				break
+			}
+
+			sb.WriteString(class)
+			if i+1 < len(pattern) && (pattern[i+1] == '+' || pattern[i+1] == '*') {
+				i++
+				sb.WriteByte(pattern[i])
+				if osRegexHasMore(pattern[i+1:]) {
+					sb.WriteByte('?')
+				}
+			}
+		case c == '^' && atStart:
+			sb.WriteByte('^')
+		case c == '$' && (i == len(pattern)-1 || pattern[i+1] == '|'):
+			sb.WriteByte('$')
+		case c == '(' || c == ')' || c == '|':
+			sb.WriteByte(c)
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+
+		atStart = c == '|'
+	}
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %s", pattern, err)
+	}
+
+	return regexMatcher{re}, nil
+}
+
+// Whether anything other than group ends and an end
+// anchor follows in the current alternative
+func osRegexHasMore(rest string) bool {
+	if end := strings.IndexByte(rest, '|'); end >= 0 {
+		rest = rest[:end]
+	}
+	rest = strings.ReplaceAll(rest, ")", "")
+
+	return rest != "" && rest != "$"
+}
+
+// A Wazuh match (OS_Match) pattern: literal strings separated
+// by | with optional ^ and $ anchors, case insensitive. A
+// leading ! negates the whole pattern.
+type osMatcher struct {
+	alternatives []osMatchString
+	negate       bool
+}
+
+type osMatchString struct {
+	text    string
+	atStart bool
+	atEnd   bool
+}
+
+func compileOSMatch(pattern string) (patternMatcher, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("match pattern is empty")
+	}
+
+	matcher := osMatcher{}
+	if strings.HasPrefix(pattern, "!") {
+		matcher.negate = true
+		pattern = pattern[1:]
+	}
+
+	for _, alt := range strings.Split(pattern, "|") {
+		str := osMatchString{}
+		if strings.HasPrefix(alt, "^") {
+			str.atStart = true
+			alt = alt[1:]
+		}
+		if strings.HasSuffix(alt, "$") {
+			str.atEnd = true
+			alt = alt[:len(alt)-1]
+		}
+		str.text = strings.ToLower(alt)
+		matcher.alternatives = append(matcher.alternatives, str)
+	}
+
+	return matcher, nil
+}
+
+func (m osMatcher) find(value string) []int {
+	lower := strings.ToLower(value)
+
+	var loc []int
+	for _, alt := range m.alternatives {
+		switch {
+		case alt.atStart && alt.atEnd:
+			if lower == alt.text {
+				loc = []int{0, len(value)}
+			}
+		case alt.atStart:
+			if strings.HasPrefix(lower, alt.text) {
+				loc = []int{0, len(alt.text)}
+			}
+		case alt.atEnd:
+			if strings.HasSuffix(lower, alt.text) {
+				loc = []int{len(value) - len(alt.text), len(value)}
+			}
+		default:
+			if index := strings.Index(lower, alt.text); index >= 0 {
+				loc = []int{index, index + len(alt.text)}
+			}
+		}
+
+		if loc != nil {
+			break
+		}
+	}
+
+	if m.negate {
+		if loc != nil {
+			return nil
+		}
+		return []int{0, 0}
+	}
+
+	return loc
+}
+
+// Matches an IP address against an address or CIDR block
+// such as those used in <srcip> and <dstip>. A leading !
+// negates the match.
+type ipMatcher struct {
+	network *net.IPNet
+	negate  bool
+}
+
+func compileIPMatch(pattern string) (patternMatcher, error) {
+	matcher := ipMatcher{}
+	if strings.HasPrefix(pattern, "!") {
+		matcher.negate = true
+		pattern = pattern[1:]
+	}
+
+	if !strings.Contains(pattern, "/") {
+		if ip := net.ParseIP(pattern); ip != nil && ip.To4() != nil {
+			pattern += "/32"
+		} else {
+			pattern += "/128"
+		}
+	}
+
+	_, network, err := net.ParseCIDR(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP address %q", pattern)
+	}
+	matcher.network = network
+
+	return matcher, nil
+}
+
+func (m ipMatcher) find(value string) []int {
+	ip := net.ParseIP(value)
+	matched := ip != nil && m.network.Contains(ip)
+
+	if matched != m.negate {
+		return []int{0, len(value)}
+	}
+	return nil
+}
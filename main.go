@@ -1,27 +1,274 @@
 package main
 
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
 // main
 func main() {
 
 	args := parseArguments()
 
+	os.Exit(run(args))
+}
+
+// Runs the selected mode and returns the exit code. Exiting
+// from main instead of here lets deferred cleanup, such as
+// restoring a deployed ruleset, always run.
+func run(args Arguments) int {
+
+	con := newConsole(args)
+
+	if args.Mode == AnalyzeMode {
+		return runAnalyze(con, args)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if args.Backend == BackendLocal {
+		engine, err := newLocalEngine(args.LocalRuleset)
+		if err != nil {
+			con.red("Error loading local ruleset: " + err.Error())
+			return 1
+		}
+		printLocalEngineSummary(con, engine, args.Verbosity)
+
+		if args.Watch {
+			return runWatch(ctx, engine, args)
+		}
+
+		reporter := newRunReporter(con, args)
+		testDirs, err := runner.RunTestGroup(ctx, engine, args.TestsDir, args.RunFilter, args.Threads, reporter)
+		if err != nil {
+			con.red("Error running tests: " + err.Error())
+			return 1
+		}
+
+		return finishRun(con, nil, testDirs, args, reporter)
+	}
+
+	if len(args.Managers) > 0 {
+		return runCompare(ctx, args)
+	}
+
 	// Initialize the WazuhServer object
-	wazuhServer, err := NewWazuhServer(args.User, args.Password, args.Host, args.Timeout, args.TlsLogPath)
+	con.white("Authenticating to manager: " + args.Host)
+	wazuhServer, err := wazuh.NewWazuhServer(args.User, args.Password, args.Host, args.Timeout, args.TlsLogPath)
 	if err != nil {
-		PrintRed("Error initializing WazuhServer object: " + err.Error())
-		return
+		con.red("Error initializing WazuhServer object: " + err.Error())
+		return 1
 	}
+	con.green("Sucessfully authenticated to manager.")
 
-	wazuhServer.checkConnection(args.Verbosity)
+	reporter := newRunReporter(con, args)
 
-	numTests, numFailedTests, numWarnTests, err := runTestGroup(wazuhServer, args.TestsDir, args.Threads, args.Verbosity, args.CliMode)
+	info, err := wazuhServer.CheckConnection()
 	if err != nil {
-		panic(err)
+		con.red("Error verifying connection to manager: " + err.Error())
+	} else {
+		reporter.Connection(info)
+	}
+
+	if len(args.RulesetDir) > 0 {
+		// An interrupt only cancels the run. The ruleset is
+		// restored on return once nothing else is being sent
+		// to the manager. Interrupts keep being caught until
+		// the restore is done.
+		stop := cancelOnInterrupt(con, cancel)
+		defer stop()
+
+		deployment := newRulesetDeployment(wazuhServer, args.RulesetApply, time.Duration(args.RulesetWait)*time.Second, con)
+		defer func() {
+			if err := deployment.restore(); err != nil {
+				con.red("Error restoring ruleset: " + err.Error())
+			}
+		}()
+
+		if err := deployment.deploy(ctx, args.RulesetDir); err != nil {
+			con.red("Error deploying ruleset: " + err.Error())
+			return 1
+		}
+	}
+
+	if args.Watch {
+		return runWatch(ctx, wazuhServer, args)
+	}
+
+	testDirs, err := runner.RunTestGroup(ctx, wazuhServer, args.TestsDir, args.RunFilter, args.Threads, reporter)
+	if ctx.Err() != nil {
+		con.yellow("Run interrupted.")
+		return 1
+	}
+	if err != nil {
+		con.red("Error running tests: " + err.Error())
+		return 1
+	}
+
+	return finishRun(con, wazuhServer, testDirs, args, reporter)
+}
+
+// The reporters of a test run. When stdout holds a machine
+// readable format con already writes to stderr.
+func newRunReporter(con console, args Arguments) runner.Reporter {
+	var reporters runner.MultiReporter
+
+	if args.Format == FormatTAP {
+		reporters = append(reporters, &tapReporter{w: os.Stdout}, newConsoleReporter(con, args.Verbosity, false))
+	} else {
+		reporters = append(reporters, newConsoleReporter(con, args.Verbosity, !args.CliMode))
+	}
+
+	if len(args.ReportHTML) > 0 {
+		reporters = append(reporters, fileReporter{con: con, name: "HTML", path: args.ReportHTML, save: saveHTMLReport})
+	}
+
+	if len(args.ReportMarkdown) > 0 {
+		reporters = append(reporters, fileReporter{con: con, name: "Markdown", path: args.ReportMarkdown, save: saveMarkdownReport})
 	}
 
-	printSummary(numTests, numFailedTests, numWarnTests)
+	return reporters
+}
+
+// Prints the summary and reports of a test run and returns
+// the exit code. ws is nil when the local backend was used.
+func finishRun(con console, ws *wazuh.WazuhServer, testDirs []runner.TestDir, args Arguments, reporter runner.Reporter) int {
+	if args.Slowest > 0 {
+		printSlowestTests(con, testDirs, args.Slowest)
+	}
+
+	summary := runner.SummarizeResults(testDirs)
+	reporter.Summary(testDirs, summary)
+
+	if len(args.ComplianceReport) > 0 {
+		err := writeComplianceReport(con, ws, testDirs, args.ComplianceReport)
+		if err != nil {
+			con.red("Error writing compliance report: " + err.Error())
+		} else {
+			con.white("Compliance report written to: " + args.ComplianceReport)
+		}
+	}
+
+	// Compare before saving so the same
+	// file can be used for both
+	drifted := false
+	if len(args.CompareBaseline) > 0 || len(args.SaveBaseline) > 0 {
+		current := buildBaseline(testDirs)
+		focused := isFocused(testDirs)
+
+		// Like -run, a partial baseline would report every
+		// other test as removed later on
+		if focused && len(args.SaveBaseline) > 0 {
+			con.red("Cannot save a baseline while focus mode is active")
+			return 1
+		}
+
+		if len(args.CompareBaseline) > 0 {
+			old, err := loadBaseline(args.CompareBaseline)
+			if err != nil {
+				con.red("Error loading baseline: " + err.Error())
+				return 1
+			}
+
+			pruneBaseline(old, current, args.RunFilter, focused)
+
+			numDrifted := printBaselineComparison(con, compareBaseline(old, current), args.CompareBaseline)
+			drifted = numDrifted > 0
+		}
+
+		if len(args.SaveBaseline) > 0 {
+			err := saveBaseline(args.SaveBaseline, current)
+			if err != nil {
+				con.red("Error writing baseline: " + err.Error())
+			} else {
+				con.white("Baseline written to: " + args.SaveBaseline)
+			}
+		}
+	}
+
+	if args.Mode == CoverageMode {
+		report, err := getRuleCoverage(ws, testDirs, args.RuleFilename, args.RuleDirname)
+		if err != nil {
+			con.red("Error measuring rule coverage: " + err.Error())
+			return 1
+		}
+
+		printRuleCoverage(con, report, args.Verbosity)
+
+		decoderReport, err := getDecoderCoverage(ws, testDirs, args.DecoderFilename, args.DecoderDirname)
+		if err != nil {
+			con.red("Error measuring decoder coverage: " + err.Error())
+			return 1
+		}
+
+		printDecoderCoverage(con, decoderReport, args.Verbosity)
+
+		// Fail even without cli mode since a minimum
+		// was explicitly requested
+		if report.percentage() < args.MinRuleCoverage {
+			con.red(fmt.Sprintf("Rule coverage %.1f%% is below the minimum of %.1f%%", report.percentage(), args.MinRuleCoverage))
+			return 1
+		}
+	}
+
+	// Like the minimum coverage this was
+	// explicitly requested so fail without cli mode
+	if drifted && args.FailOnDrift {
+		con.red("Test results changed since the baseline")
+		return 1
+	}
 
 	if args.CliMode {
-		cliExit(numFailedTests)
+		return cliExitCode(summary.Failed)
+	}
+
+	return 0
+}
+
+// Statically checks rule and decoder files without a manager
+func runAnalyze(con console, args Arguments) int {
+	analysis, err := analyzeRulesetPaths(args.AnalyzePaths, args.RefPaths)
+	if err != nil {
+		con.red("Error analyzing ruleset: " + err.Error())
+		return 1
+	}
+
+	printRulesetAnalysis(con, analysis, len(args.RefPaths) > 0)
+
+	if analysis.numIssues(issueError) > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// Cancels the run on Ctrl-C or SIGTERM instead of exiting
+// so deferred cleanup still runs. The returned function
+// stops catching the signals.
+func cancelOnInterrupt(con console, cancel context.CancelFunc) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+			con.yellow("Interrupted, stopping the run...")
+			cancel()
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
 	}
 }
@@ -0,0 +1,11 @@
+package main
+
+import "testing"
+
+// Connection errors must fail CI in cli mode
+func Test_runConnectionError(t *testing.T) {
+	args := Arguments{Host: "localhost", User: "wazuh", Timeout: 1, CliMode: true, Format: FormatTAP}
+	if got := run(args); got != 1 {
+		t.Errorf("run() = %d, want 1 without a password", got)
+	}
+}
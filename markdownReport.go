@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alexchristy/WazuhTest/runner"
+)
+
+// GitHub comments are limited to 65536 characters. Some
+// room is left for text that bots add around the report.
+const markdownReportLimit = 60000
+
+// Longest error, value or log line shown before it is cut
+const markdownValueLimit = 300
+
+var statusIcons = map[string]string{
+	runner.StatusPassed:         "✅",
+	runner.StatusFailed:         "❌",
+	runner.StatusSkipped:        "⏭️",
+	runner.StatusExpectedFail:   "☑️",
+	runner.StatusUnexpectedPass: "⚠️",
+}
+
+// Writes a Markdown report of the test results to path
+func saveMarkdownReport(testDirs []runner.TestDir, path string) error {
+	content := renderMarkdownReport(buildTestReport(testDirs), markdownReportLimit)
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// Renders the report in at most limit bytes. The totals
+// always come first. The directory table, failed tests and
+// load issues are added while they fit and the rest are
+// counted in a note at the end.
+func renderMarkdownReport(report testReport, limit int) string {
+	var sb strings.Builder
+	summary := report.Summary
+
+	sb.WriteString("## WazuhTest Results\n\n")
+
+	invalid := 0
+	for _, dir := range report.Dirs {
+		invalid += dir.InvalidTests
+	}
+	passed := summary.Total - summary.Failed - summary.Skipped - summary.ExpectedFailures - summary.UnexpectedPasses
+	sb.WriteString("| Total | Passed | Failed | Warned | Skipped | Expected failures | Unexpectedly passed | Failed to load |\n")
+	sb.WriteString("|---|---|---|---|---|---|---|---|\n")
+	fmt.Fprintf(&sb, "| %d | %d | %d | %d | %d | %d | %d | %d |\n\n", summary.Total, passed, summary.Failed, summary.Warned, summary.Skipped, summary.ExpectedFailures, summary.UnexpectedPasses, invalid)
+
+	// Everything below is added in pieces that either
+	// fit completely or are left out
+	var sections []markdownSection
+
+	if len(report.Dirs) > 0 {
+		sections = append(sections, markdownSection{text: "### Directories\n\n| Directory | Tests | Passed | Failed | Skipped | Failed to load |\n|---|---|---|---|---|---|\n"})
+		for _, dir := range report.Dirs {
+			counts := map[string]int{}
+			for _, test := range dir.Tests {
+				counts[test.Status]++
+			}
+			row := fmt.Sprintf("| %s | %d | %d | %d | %d | %d |\n", escapeMarkdownCell(dir.Path), len(dir.Tests), counts[runner.StatusPassed], counts[runner.StatusFailed], counts[runner.StatusSkipped], dir.InvalidTests)
+			sections = append(sections, markdownSection{text: row, kind: "directories"})
+		}
+		sections = append(sections, markdownSection{text: "\n"})
+	}
+
+	var failed []testReportTest
+	for _, dir := range report.Dirs {
+		for _, test := range dir.Tests {
+			if test.Status == runner.StatusFailed || test.Status == runner.StatusUnexpectedPass {
+				failed = append(failed, test)
+			}
+		}
+	}
+	if len(failed) > 0 {
+		sections = append(sections, markdownSection{text: "### Failed Tests\n\n"})
+		for _, test := range failed {
+			sections = append(sections, markdownSection{text: renderMarkdownTest(test), kind: "failed tests"})
+		}
+	}
+
+	var loadErrors, loadWarnings []string
+	for _, dir := range report.Dirs {
+		for _, issue := range dir.LoadIssues {
+			for _, e := range issue.Errors {
+				loadErrors = append(loadErrors, fmt.Sprintf("- %s: %s\n", escapeMarkdownHTML(truncateMarkdownValue(issue.GetName())), escapeMarkdownHTML(truncateMarkdownValue(e))))
+			}
+			for _, w := range issue.Warnings {
+				loadWarnings = append(loadWarnings, fmt.Sprintf("- %s: %s\n", escapeMarkdownHTML(truncateMarkdownValue(issue.GetName())), escapeMarkdownHTML(truncateMarkdownValue(w))))
+			}
+		}
+	}
+	for _, list := range []struct {
+		title string
+		items []string
+	}{{"Load Errors", loadErrors}, {"Load Warnings", loadWarnings}} {
+		if len(list.items) == 0 {
+			continue
+		}
+		sections = append(sections, markdownSection{text: "### " + list.title + "\n\n"})
+		for _, item := range list.items {
+			sections = append(sections, markdownSection{text: item, kind: strings.ToLower(list.title)})
+		}
+		sections = append(sections, markdownSection{text: "\n"})
+	}
+
+	// Leave room for the note
+	budget := limit - sb.Len() - 300
+	var left []string
+	leftCounts := map[string]int{}
+	for _, section := range sections {
+		if len(section.text) > budget {
+			if section.kind != "" {
+				if leftCounts[section.kind] == 0 {
+					left = append(left, section.kind)
+				}
+				leftCounts[section.kind]++
+			}
+			continue
+		}
+		sb.WriteString(section.text)
+		budget -= len(section.text)
+	}
+
+	if len(left) > 0 {
+		var parts []string
+		for _, kind := range left {
+			parts = append(parts, strconv.Itoa(leftCounts[kind])+" "+kind)
+		}
+		fmt.Fprintf(&sb, "\n> **Note:** The report was truncated to fit in %d characters. Not shown: %s. Use -report-html for the full results.\n", limit, strings.Join(parts, ", "))
+	}
+
+	return sb.String()
+}
+
+// A piece of the report. Pieces of a kind are counted
+// when they are left out.
+type markdownSection struct {
+	text string
+	kind string
+}
+
+// A collapsible section with the errors of a failed test
+// and the expected fields next to the returned ones
+func renderMarkdownTest(test testReportTest) string {
+	var sb strings.Builder
+
+	title := test.ID
+	if test.Label != "" {
+		title += " (" + test.Label + ")"
+	}
+	if test.Description != "" {
+		title += " " + test.Description
+	}
+	fmt.Fprintf(&sb, "<details>\n<summary>%s %s</summary>\n\n", statusIcons[test.Status], escapeMarkdownHTML(truncateMarkdownValue(title)))
+
+	if test.Status == runner.StatusUnexpectedPass {
+		sb.WriteString("Marked ExpectFail but passed: " + escapeMarkdownHTML(truncateMarkdownValue(test.Reason)) + "\n\n")
+	}
+
+	for _, e := range test.Errors {
+		sb.WriteString("- " + escapeMarkdownHTML(truncateMarkdownValue(e)) + "\n")
+	}
+	if len(test.Errors) > 0 {
+		sb.WriteString("\n")
+	}
+
+	if len(test.Fields) > 0 {
+		sb.WriteString("| Field | Expected | Got |\n|---|---|---|\n")
+		for _, field := range test.Fields {
+			mark := ""
+			if field.Expected != field.Got {
+				mark = " ❗"
+			}
+			fmt.Fprintf(&sb, "| %s | %s | %s%s |\n", escapeMarkdownCell(field.Name), escapeMarkdownCell(field.Expected), escapeMarkdownCell(field.Got), mark)
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("</details>\n\n")
+	return sb.String()
+}
+
+func truncateMarkdownValue(value string) string {
+	runes := []rune(value)
+	if len(runes) <= markdownValueLimit {
+		return value
+	}
+	return string(runes[:markdownValueLimit]) + "…"
+}
+
+// Table cells cannot hold pipes or line breaks
+func escapeMarkdownCell(value string) string {
+	value = escapeMarkdownHTML(truncateMarkdownValue(value))
+	value = strings.ReplaceAll(value, "|", "\\|")
+	value = strings.ReplaceAll(value, "\r", "")
+	return strings.ReplaceAll(value, "\n", "<br>")
+}
+
+// Keeps text from being read as HTML tags, which GitHub
+// renders in comments
+func escapeMarkdownHTML(value string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(value)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+func markdownTestDirs(numFailed int) []runner.TestDir {
+	dir := runner.TestDir{TestDir: testdef.TestDir{
+		Path: "tests/sshd",
+		LoadIssues: []testdef.LoadIssue{
+			{Path: "tests/sshd/5712.json", Errors: []string{"RuleID must be a number"}},
+			{Path: "tests/sshd/5713.json", Warnings: []string{"No log file found"}},
+		},
+		InvalidTests: 1,
+	}}
+
+	response := wazuh.Response{Raw: map[string]interface{}{}}
+	response.Data.Output.Rule = wazuh.Rule{ID: "5501", Level: 3, Description: "Login session opened."}
+	response.Data.Output.Decoder = map[string]string{"name": "pam"}
+
+	for i := 0; i < numFailed; i++ {
+		dir.Tests = append(dir.Tests, testdef.LogTest{ID: "sshd/5715.json#" + string(rune('a'+i%26)), TestDescription: "SSH login <success>", RuleID: "5715", RuleLevel: "3", Decoder: map[string]string{"name": "sshd"}})
+		dir.Results = append(dir.Results, runner.Result{Errors: []string{"Expected rule ID 5715, got 5501"}, Response: response})
+	}
+	dir.Tests = append(dir.Tests, testdef.LogTest{ID: "sshd/5710.json#1", RuleID: "5710", RuleLevel: "5"})
+	dir.Results = append(dir.Results, runner.Result{Passed: true})
+
+	return []runner.TestDir{dir}
+}
+
+func Test_renderMarkdownReport(t *testing.T) {
+	got := renderMarkdownReport(buildTestReport(markdownTestDirs(1)), markdownReportLimit)
+
+	for _, want := range []string{
+		"| 2 | 1 | 1 | 0 | 0 | 0 | 0 | 1 |",
+		"| tests/sshd | 2 | 1 | 1 | 0 | 1 |",
+		"<summary>❌ sshd/5715.json#a (RuleID: 5715) SSH login &lt;success&gt;</summary>",
+		"| RuleID | 5715 | 5501 ❗ |",
+		"| RuleLevel | 3 | 3 |",
+		"| decoder.name | sshd | pam ❗ |",
+		"### Load Errors\n\n- tests/sshd/5712.json: Test #1: RuleID must be a number",
+		"### Load Warnings\n\n- tests/sshd/5713.json: Test #1: No log file found",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderMarkdownReport() is missing %q", want)
+		}
+	}
+	if strings.Contains(got, "truncated") {
+		t.Errorf("renderMarkdownReport() was truncated")
+	}
+}
+
+func Test_renderMarkdownReportTruncated(t *testing.T) {
+	limit := 3000
+	got := renderMarkdownReport(buildTestReport(markdownTestDirs(20)), limit)
+
+	if len(got) > limit {
+		t.Errorf("renderMarkdownReport() is %d bytes, want at most %d", len(got), limit)
+	}
+	if !strings.Contains(got, "> **Note:** The report was truncated") {
+		t.Errorf("renderMarkdownReport() has no truncation note")
+	}
+	shown := strings.Count(got, "<details>")
+	if shown == 0 || shown == 20 {
+		t.Fatalf("renderMarkdownReport() shows %d of 20 failed tests", shown)
+	}
+	if want := "Not shown: " + strconv.Itoa(20-shown) + " failed tests"; !strings.Contains(got, want) {
+		t.Errorf("renderMarkdownReport() note is missing %q", want)
+	}
+}
+
+func Test_compareFields(t *testing.T) {
+	response := wazuh.Response{Raw: map[string]interface{}{}}
+	response.Data.Output.Rule = wazuh.Rule{ID: "5716", Level: 5}
+	response.Data.Output.Data = map[string]interface{}{"srcip": "10.0.0.1"}
+
+	sequence := testdef.LogTest{Sequence: []testdef.SequenceStep{
+		{RuleID: "5715", Repeat: 2},
+		{RuleID: "5716", RuleLevel: "10", Decoder: map[string]string{"srcip": "10.0.0.1", "srcuser": "bob"}},
+	}}
+	result := runner.Result{Response: response, Steps: []wazuh.Response{response, response, response}}
+
+	got := compareFields(&sequence, result)
+	want := []fieldComparison{
+		{Name: "RuleID", Expected: "5716", Got: "5716"},
+		{Name: "RuleLevel", Expected: "10", Got: "5"},
+		{Name: "decoder.srcip", Expected: "10.0.0.1", Got: "10.0.0.1"},
+		{Name: "decoder.srcuser", Expected: "bob", Got: "(missing)"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("compareFields() = %v, want %v", got, want)
+	}
+
+	// The second event was a repeat of the first step
+	result.Steps = result.Steps[:2]
+	if got := compareFields(&sequence, result); len(got) != 1 || got[0].Expected != "5715" {
+		t.Errorf("compareFields() = %v, want the first step", got)
+	}
+}
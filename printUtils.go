@@ -2,49 +2,79 @@ package main
 
 import (
 	"fmt"
-	"strconv"
+	"io"
+	"os"
 )
 
-func PrintRed(text string) {
-	fmt.Println("\033[91m" + text + "\033[0m")
+// Where the console output of a mode is written. Machine
+// readable formats move it to stderr without colors to keep
+// stdout for themselves.
+type console struct {
+	out   io.Writer
+	color bool
 }
 
-func PrintGreen(text string) {
-	fmt.Println("\033[92m" + text + "\033[0m")
+func newConsole(args Arguments) console {
+	if args.Format == FormatTAP {
+		return console{out: os.Stderr}
+	}
+	return console{out: os.Stdout, color: shouldUseColor(os.Stdout)}
 }
 
-func PrintYellow(text string) {
-	fmt.Println("\033[93m" + text + "\033[0m")
+// Colors are only used on a terminal and never when the
+// NO_COLOR environment variable is set (https://no-color.org)
+func shouldUseColor(file *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(file)
 }
 
-func PrintWhite(text string) {
-	fmt.Println("\033[97m" + text + "\033[0m")
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
 }
 
-func PrintBoldWhite(text string) {
-	fmt.Println("\033[97m\033[1m" + text + "\033[0m")
+func printColor(w io.Writer, useColor bool, color string, text string) {
+	if !useColor {
+		fmt.Fprintln(w, text)
+		return
+	}
+	fmt.Fprintln(w, color+text+"\033[0m")
 }
 
-func printSummary(numTests int, numFailedTests int, numWarnTests int) error {
-
-	PrintBoldWhite("Test Summary:")
-	PrintBoldWhite("=============\n")
+// ANSI codes used by the print methods
+const (
+	colorRed       = "\033[91m"
+	colorGreen     = "\033[92m"
+	colorYellow    = "\033[93m"
+	colorWhite     = "\033[97m"
+	colorBoldWhite = "\033[97m\033[1m"
+)
 
-	fmt.Printf("Total: %d\n", numTests)
+func (c console) red(text string) {
+	printColor(c.out, c.color, colorRed, text)
+}
 
-	if numFailedTests > 0 {
-		PrintRed("Failed: " + strconv.Itoa(numFailedTests))
-	}
+func (c console) green(text string) {
+	printColor(c.out, c.color, colorGreen, text)
+}
 
-	if numWarnTests > 0 {
-		PrintYellow("Warned: " + strconv.Itoa(numWarnTests))
-	}
+func (c console) yellow(text string) {
+	printColor(c.out, c.color, colorYellow, text)
+}
 
-	fmt.Printf("\n")
+func (c console) white(text string) {
+	printColor(c.out, c.color, colorWhite, text)
+}
 
-	if numFailedTests <= 0 {
-		PrintGreen("All tests passed.")
-	}
+func (c console) boldWhite(text string) {
+	printColor(c.out, c.color, colorBoldWhite, text)
+}
 
-	return nil
+func (c console) printf(format string, a ...interface{}) {
+	fmt.Fprintf(c.out, format, a...)
 }
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+)
+
+// The results of a run as they are written to report files
+type testReport struct {
+	Generated string
+	Summary   runner.Summary
+	Dirs      []testReportDir
+}
+
+type testReportDir struct {
+	Path         string
+	InvalidTests int
+	LoadIssues   []testdef.LoadIssue
+	Tests        []testReportTest
+}
+
+type testReportTest struct {
+	ID          string
+	Description string
+	Label       string
+	Status      string
+
+	// Why the test is skipped or expected to fail
+	Reason string
+
+	Duration time.Duration
+	Errors   []string
+	Warnings []string
+
+	// The events that were sent, the expectations of the
+	// test and every response, as indented JSON
+	Events    []string
+	Expected  string
+	Responses []string
+
+	// The expected rule and decoder fields next to the
+	// ones returned in the last response
+	Fields []fieldComparison
+}
+
+type fieldComparison struct {
+	Name     string
+	Expected string
+	Got      string
+}
+
+func buildTestReport(testDirs []runner.TestDir) testReport {
+	report := testReport{
+		Generated: time.Now().Format(time.RFC1123),
+		Summary:   runner.SummarizeResults(testDirs),
+	}
+
+	for _, dir := range testDirs {
+		reportDir := testReportDir{Path: dir.Path, InvalidTests: dir.InvalidTests, LoadIssues: dir.LoadIssues}
+
+		for i, test := range dir.Tests {
+			result := dir.Results[i]
+			reportTest := testReportTest{
+				ID:          test.ID,
+				Description: test.GetDisplayName(),
+				Label:       test.GetRuleLabel(),
+				Status:      result.GetStatus(),
+				Reason:      test.Skip + test.ExpectFail,
+				Duration:    result.Duration,
+				Errors:      result.Errors,
+				Warnings:    result.Warnings,
+				Events:      result.Events,
+				Expected:    formatIndentedJSON(test.GetExpectations()),
+			}
+
+			// Tests that failed before getting a
+			// response have nothing to show
+			for _, response := range result.GetResponses() {
+				if response.Raw != nil {
+					reportTest.Responses = append(reportTest.Responses, formatIndentedJSON(response.Raw))
+				}
+			}
+			if result.Response.Raw != nil && !result.Skipped {
+				reportTest.Fields = compareFields(&test, result)
+			}
+
+			reportDir.Tests = append(reportDir.Tests, reportTest)
+		}
+
+		if len(reportDir.Tests) > 0 || len(reportDir.LoadIssues) > 0 {
+			report.Dirs = append(report.Dirs, reportDir)
+		}
+	}
+
+	return report
+}
+
+// Lines up the expected rule and decoder fields with the
+// last response. For sequence tests this is the step that
+// the last event was sent for.
+func compareFields(lt *testdef.LogTest, result runner.Result) []fieldComparison {
+	ruleID, ruleLevel, ruleDescription := lt.GetRuleID(), lt.GetRuleLevel(), lt.GetRuleDescription()
+	predecoder, decoder := lt.GetPredecoder(), lt.GetDecoder()
+
+	if lt.IsSequence() {
+		step, ok := lt.GetStepOfEvent(len(result.Steps) - 1)
+		if !ok {
+			return nil
+		}
+		ruleID, ruleLevel, ruleDescription = step.RuleID, step.RuleLevel, step.RuleDescription
+		predecoder, decoder = step.Predecoder, step.Decoder
+	}
+
+	output := result.Response.Data.Output
+	var fields []fieldComparison
+
+	if ruleID != "" {
+		fields = append(fields, fieldComparison{Name: "RuleID", Expected: ruleID, Got: output.Rule.ID})
+	}
+	if ruleLevel != "" {
+		fields = append(fields, fieldComparison{Name: "RuleLevel", Expected: ruleLevel, Got: strconv.Itoa(output.Rule.Level)})
+	}
+	if ruleDescription != "" {
+		fields = append(fields, fieldComparison{Name: "RuleDescription", Expected: ruleDescription, Got: output.Rule.Description})
+	}
+	fields = append(fields, compareDecoderFields("predecoder.", predecoder, output.Predecoder, output.Data)...)
+	fields = append(fields, compareDecoderFields("decoder.", decoder, output.Decoder, output.Data)...)
+
+	return fields
+}
+
+// Looks up the expected decoder fields the same way
+// validateDecoder does
+func compareDecoderFields(prefix string, expected map[string]string, gotDecoder map[string]string, gotData map[string]interface{}) []fieldComparison {
+	var keys []string
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var fields []fieldComparison
+	for _, key := range keys {
+		got := "(missing)"
+		if value, ok := gotDecoder[key]; ok {
+			got = value
+		} else if value, ok := testdef.LookupJSONPath(gotData, key); ok {
+			got = testdef.FormatJSONValue(value)
+		}
+		fields = append(fields, fieldComparison{Name: prefix + key, Expected: expected[key], Got: got})
+	}
+	return fields
+}
+
+func formatIndentedJSON(value interface{}) string {
+	data, err := json.MarshalIndent(value, "", "    ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
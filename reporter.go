@@ -0,0 +1,22 @@
+package main
+
+import (
+	"github.com/alexchristy/WazuhTest/runner"
+)
+
+// Writes a report file once the run is over
+type fileReporter struct {
+	runner.NopReporter
+	con  console
+	name string
+	path string
+	save func(testDirs []runner.TestDir, path string) error
+}
+
+func (reporter fileReporter) Summary(testDirs []runner.TestDir, summary runner.Summary) {
+	if err := reporter.save(testDirs, reporter.path); err != nil {
+		reporter.con.red("Error writing " + reporter.name + " report: " + err.Error())
+		return
+	}
+	reporter.con.white(reporter.name + " report written to: " + reporter.path)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+)
+
+func Test_consoleReporter(t *testing.T) {
+	var out bytes.Buffer
+	console := &consoleReporter{console: console{out: &out}, verbosity: 1}
+
+	console.LoadIssue(testdef.LoadIssue{Path: "tests/test_ssh.json", Index: 1, Errors: []string{"RuleID must be a number"}})
+	console.Summary(nil, runner.Summary{Total: 3, Failed: 1, Skipped: 1})
+
+	want := "[FAILED LOAD] tests/test_ssh.json: Test #2\n+ RuleID must be a number\n\n" +
+		"Test Summary:\n=============\n\nTotal: 3\nFailed: 1\nSkipped: 1\n\n"
+	if got := out.String(); got != want {
+		t.Errorf("consoleReporter output = %q, want %q", got, want)
+	}
+
+	// Colors are only added when enabled
+	out.Reset()
+	console.color = true
+	console.Warning("Focus mode is active")
+	if got := out.String(); !strings.HasPrefix(got, colorYellow) {
+		t.Errorf("consoleReporter colored output = %q", got)
+	}
+}
+
+func Test_shouldUseColor(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "output"))
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer file.Close()
+
+	// A file is not a terminal
+	if shouldUseColor(file) {
+		t.Errorf("shouldUseColor() = true for a file")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if shouldUseColor(os.Stdout) {
+		t.Errorf("shouldUseColor() = true with NO_COLOR set")
+	}
+}
+
+// Nothing but the TAP stream may be written to stdout
+func Test_newConsole(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		wantOut *os.File
+	}{
+		{name: "Valid console on stdout", format: FormatConsole, wantOut: os.Stdout},
+		{name: "Valid TAP moves console to stderr", format: FormatTAP, wantOut: os.Stderr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			con := newConsole(Arguments{Format: tt.format})
+			if con.out != tt.wantOut {
+				t.Errorf("newConsole() out = %v, want %v", con.out, tt.wantOut)
+			}
+			if tt.format == FormatTAP && con.color {
+				t.Errorf("newConsole() uses colors on stderr with TAP")
+			}
+		})
+	}
+}
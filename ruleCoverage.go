@@ -0,0 +1,186 @@
+package main
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// Compares the manager's rule catalog with the rule IDs
+// asserted by tests and the rule IDs returned during the run.
+//
+// A rule is covered when a test asserts it and the manager
+// actually returned it for at least one test.
+type ruleCoverageReport struct {
+	TotalRules int
+
+	Covered []wazuh.ManagerRule
+
+	// Asserted by a test but never returned by the manager
+	AssertedOnly []wazuh.ManagerRule
+
+	// Returned by the manager but not asserted by any test
+	ReturnedOnly []wazuh.ManagerRule
+
+	// Neither asserted nor returned
+	Untested []wazuh.ManagerRule
+}
+
+// Fetch the rule catalog and compare it with the test run.
+// The rule file and directory filters are optional.
+func getRuleCoverage(ws *wazuh.WazuhServer, testDirs []runner.TestDir, ruleFilename string, ruleDirname string) (ruleCoverageReport, error) {
+	filters := url.Values{}
+	if ruleFilename != "" {
+		filters.Set("filename", ruleFilename)
+	}
+	if ruleDirname != "" {
+		filters.Set("relative_dirname", ruleDirname)
+	}
+
+	rules, err := ws.GetRules(filters)
+	if err != nil {
+		return ruleCoverageReport{}, err
+	}
+
+	return buildRuleCoverage(rules, testDirs), nil
+}
+
+func buildRuleCoverage(rules []wazuh.ManagerRule, testDirs []runner.TestDir) ruleCoverageReport {
+	asserted := map[string]struct{}{}
+	returned := map[string]struct{}{}
+
+	for _, testDir := range testDirs {
+		for i, test := range testDir.Tests {
+			// Skipped tests did not check anything
+			if i < len(testDir.Results) && testDir.Results[i].Skipped {
+				continue
+			}
+			for _, ruleID := range test.GetAssertedRuleIDs() {
+				asserted[ruleID] = struct{}{}
+			}
+		}
+
+		for _, result := range testDir.Results {
+			for _, response := range result.GetResponses() {
+				ruleID := response.Data.Output.Rule.ID
+				if ruleID != "" {
+					returned[ruleID] = struct{}{}
+				}
+			}
+		}
+	}
+
+	report := ruleCoverageReport{TotalRules: len(rules)}
+	for _, rule := range rules {
+		id := strconv.Itoa(rule.ID)
+		_, isAsserted := asserted[id]
+		_, isReturned := returned[id]
+
+		switch {
+		case isAsserted && isReturned:
+			report.Covered = append(report.Covered, rule)
+		case isAsserted:
+			report.AssertedOnly = append(report.AssertedOnly, rule)
+		case isReturned:
+			report.ReturnedOnly = append(report.ReturnedOnly, rule)
+		default:
+			report.Untested = append(report.Untested, rule)
+		}
+	}
+
+	return report
+}
+
+// Percentage of rules in the catalog that are covered. An
+// empty catalog is fully covered.
+func (report ruleCoverageReport) percentage() float64 {
+	if report.TotalRules == 0 {
+		return 100
+	}
+
+	return float64(len(report.Covered)) / float64(report.TotalRules) * 100
+}
+
+func printRuleCoverage(con console, report ruleCoverageReport, verbosity int) {
+	con.boldWhite("Rule Coverage:")
+	con.boldWhite("==============\n")
+
+	con.printf("Rules: %d\n", report.TotalRules)
+	con.printf("Covered: %d\n", len(report.Covered))
+
+	if len(report.AssertedOnly) > 0 {
+		con.red("Asserted but never returned: " + strconv.Itoa(len(report.AssertedOnly)))
+	}
+
+	if len(report.ReturnedOnly) > 0 {
+		con.yellow("Returned but not asserted: " + strconv.Itoa(len(report.ReturnedOnly)))
+	}
+
+	if len(report.Untested) > 0 {
+		con.yellow("Untested: " + strconv.Itoa(len(report.Untested)))
+	}
+
+	con.printf("Coverage: %.1f%%\n\n", report.percentage())
+
+	if len(report.AssertedOnly) > 0 {
+		con.red("Rules asserted by tests but never returned:")
+		printRulesByFile(con, report.AssertedOnly, con.red)
+	}
+
+	if len(report.Untested) > 0 {
+		con.yellow("Untested rules:")
+		printRulesByFile(con, report.Untested, con.yellow)
+	}
+
+	// These are hit incidentally and can become
+	// tests with little effort
+	if len(report.ReturnedOnly) > 0 && verbosity > 0 {
+		con.white("Rules returned but not asserted by any test:")
+		printRulesByFile(con, report.ReturnedOnly, con.white)
+	}
+}
+
+// Prints rules grouped by their file and then by level
+// from the highest level to the lowest
+func printRulesByFile(con console, rules []wazuh.ManagerRule, print func(string)) {
+	byFile := map[string][]wazuh.ManagerRule{}
+	for _, rule := range rules {
+		file := rule.Filename
+		if rule.RelativeDirname != "" {
+			file = rule.RelativeDirname + "/" + rule.Filename
+		}
+		byFile[file] = append(byFile[file], rule)
+	}
+
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		fileRules := byFile[file]
+		sort.Slice(fileRules, func(i, j int) bool {
+			if fileRules[i].Level != fileRules[j].Level {
+				return fileRules[i].Level > fileRules[j].Level
+			}
+			return fileRules[i].ID < fileRules[j].ID
+		})
+
+		print("  " + file + " (" + strconv.Itoa(len(fileRules)) + ")")
+		level := -1
+		for _, rule := range fileRules {
+			if rule.Level != level {
+				level = rule.Level
+				print("    Level " + strconv.Itoa(level) + ":")
+			}
+			print("      " + strconv.Itoa(rule.ID) + " " + strings.TrimSpace(rule.Description))
+		}
+	}
+
+	con.printf("\n")
+}
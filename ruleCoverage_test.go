@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+func Test_buildRuleCoverage(t *testing.T) {
+	rules := []wazuh.ManagerRule{
+		{ID: 5710, Level: 5, Filename: "0095-sshd_rules.xml"},
+		{ID: 5712, Level: 10, Filename: "0095-sshd_rules.xml"},
+		{ID: 100001, Level: 7, Filename: "local_rules.xml"},
+		{ID: 100002, Level: 3, Filename: "local_rules.xml"},
+	}
+
+	testDirs := []runner.TestDir{
+		{
+			TestDir: testdef.TestDir{Tests: []testdef.LogTest{{RuleID: "5710"}, {RuleID: "100001"}}},
+			Results: []runner.Result{
+				{Passed: true, Response: wazuh.Response{Data: wazuh.Data{Output: wazuh.Output{Rule: wazuh.Rule{ID: "5710"}}}}},
+				{Passed: false, Response: wazuh.Response{Data: wazuh.Data{Output: wazuh.Output{Rule: wazuh.Rule{ID: "5712"}}}}},
+			},
+		},
+	}
+
+	report := buildRuleCoverage(rules, testDirs)
+
+	checks := []struct {
+		name  string
+		rules []wazuh.ManagerRule
+		want  []int
+	}{
+		{name: "Covered", rules: report.Covered, want: []int{5710}},
+		{name: "AssertedOnly", rules: report.AssertedOnly, want: []int{100001}},
+		{name: "ReturnedOnly", rules: report.ReturnedOnly, want: []int{5712}},
+		{name: "Untested", rules: report.Untested, want: []int{100002}},
+	}
+	for _, check := range checks {
+		if len(check.rules) != len(check.want) {
+			t.Errorf("buildRuleCoverage() %s = %v, want %v", check.name, check.rules, check.want)
+			continue
+		}
+		for i, rule := range check.rules {
+			if rule.ID != check.want[i] {
+				t.Errorf("buildRuleCoverage() %s = %v, want %v", check.name, check.rules, check.want)
+			}
+		}
+	}
+
+	if report.percentage() != 25 {
+		t.Errorf("percentage() = %v, want 25", report.percentage())
+	}
+
+	if empty := buildRuleCoverage(nil, testDirs); empty.percentage() != 100 {
+		t.Errorf("percentage() of an empty catalog = %v, want 100", empty.percentage())
+	}
+}
@@ -0,0 +1,612 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexchristy/WazuhTest/testdef"
+)
+
+const (
+	issueError   = "error"
+	issueWarning = "warning"
+)
+
+// A problem found in a rule or decoder file
+type rulesetIssue struct {
+	File     string
+	Line     int
+	Severity string
+	Message  string
+}
+
+func (issue rulesetIssue) String() string {
+	return issue.File + ":" + strconv.Itoa(issue.Line) + ": " + issue.Message
+}
+
+// A rule ID referenced from another rule (e.g. in <if_sid>)
+type ruleReference struct {
+	Tag  string
+	ID   string
+	Line int
+}
+
+type xmlRule struct {
+	ID         string
+	Level      string
+	Overwrite  bool
+	File       string
+	Line       int
+	References []ruleReference
+}
+
+type xmlDecoder struct {
+	Name       string
+	Parent     string
+	File       string
+	Line       int
+	ParentLine int
+}
+
+// Everything parsed from a single rule or decoder file
+type rulesetFile struct {
+	Path     string
+	Rules    []xmlRule
+	Decoders []xmlDecoder
+
+	// Problems found while parsing such as XML
+	// syntax errors and invalid patterns
+	Issues []rulesetIssue
+}
+
+// Result of analyzing a set of rule and decoder files
+type rulesetAnalysis struct {
+	NumFiles    int
+	NumRules    int
+	NumDecoders int
+	Issues      []rulesetIssue
+
+	// True when a rule or decoder references one that
+	// was not found in the analyzed or reference files
+	HasMissingReferences bool
+}
+
+func (analysis rulesetAnalysis) numIssues(severity string) int {
+	count := 0
+	for _, issue := range analysis.Issues {
+		if issue.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// Parses every XML file in paths and reports problems with them.
+// Files in refPaths are only used to resolve rule IDs and decoder
+// names (e.g. the default Wazuh ruleset) and are not reported on.
+func analyzeRulesetPaths(paths []string, refPaths []string) (rulesetAnalysis, error) {
+	files, err := parseRulesetPaths(paths)
+	if err != nil {
+		return rulesetAnalysis{}, err
+	}
+
+	if len(files) == 0 {
+		return rulesetAnalysis{}, fmt.Errorf("no XML files found in %s", strings.Join(paths, ", "))
+	}
+
+	refFiles, err := parseRulesetPaths(refPaths)
+	if err != nil {
+		return rulesetAnalysis{}, err
+	}
+
+	return analyzeRuleset(files, refFiles), nil
+}
+
+// Parses every .xml file in the given files and directories
+func parseRulesetPaths(paths []string) ([]rulesetFile, error) {
+	var xmlPaths []string
+	for _, path := range paths {
+		found, err := findXMLFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		xmlPaths = append(xmlPaths, found...)
+	}
+	sort.Strings(xmlPaths)
+
+	files := make([]rulesetFile, 0, len(xmlPaths))
+	for _, path := range xmlPaths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, parseRulesetXML(path, content))
+	}
+
+	return files, nil
+}
+
+// Returns path if it is an XML file or every XML file
+// below it if it is a directory, sorted by path
+func findXMLFiles(path string) ([]string, error) {
+	var xmlPaths []string
+	err := filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(filePath), ".xml") {
+			xmlPaths = append(xmlPaths, filePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(xmlPaths)
+	return xmlPaths, nil
+}
+
+// Extracts the rules and decoders from a single file. An XML
+// error is reported as an issue and the rules and decoders
+// that were read before it are kept.
+func parseRulesetXML(path string, content []byte) rulesetFile {
+	file := rulesetFile{Path: path}
+
+	roots, err := parseXMLTree(content)
+	if err != nil {
+		var syntaxErr *xmlSyntaxError
+		line := 0
+		message := err.Error()
+		if errors.As(err, &syntaxErr) {
+			line = syntaxErr.Line
+			message = syntaxErr.Err.Error()
+		}
+		file.Issues = append(file.Issues, rulesetIssue{path, line, issueError, "Invalid XML: " + message})
+	}
+
+	file.addNodes(roots, nil, nil)
+	return file
+}
+
+// Walks the elements in document order. Options are read
+// from every element inside a rule or decoder.
+func (file *rulesetFile) addNodes(nodes []*xmlNode, rule *xmlRule, dec *xmlDecoder) {
+	for _, node := range nodes {
+		switch {
+		case node.Name == "rule":
+			child := &xmlRule{
+				ID:        node.Attrs["id"],
+				Level:     node.Attrs["level"],
+				Overwrite: node.Attrs["overwrite"] == "yes",
+				File:      file.Path,
+				Line:      node.Line,
+			}
+			file.addNodes(node.Children, child, nil)
+			if node.Closed {
+				file.Rules = append(file.Rules, *child)
+			}
+		case node.Name == "decoder":
+			child := &xmlDecoder{Name: node.Attrs["name"], File: file.Path, Line: node.Line}
+			file.addNodes(node.Children, nil, child)
+			if node.Closed {
+				file.Decoders = append(file.Decoders, *child)
+			}
+		default:
+			file.addNodes(node.Children, rule, dec)
+			if !node.Closed {
+				continue
+			}
+			if rule != nil {
+				file.Issues = append(file.Issues, parseRuleOption(rule, node)...)
+			} else if dec != nil {
+				file.Issues = append(file.Issues, parseDecoderOption(dec, node)...)
+			}
+		}
+	}
+}
+
+func parseRuleOption(rule *xmlRule, node *xmlNode) []rulesetIssue {
+	text := node.Text
+	switch node.Name {
+	case "if_sid", "if_matched_sid":
+		ids := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		})
+		for _, id := range ids {
+			rule.References = append(rule.References, ruleReference{Tag: node.Name, ID: id, Line: node.Line})
+		}
+	case "regex", "field":
+		return checkPattern(rule.File, node, "osregex")
+	case "match":
+		return checkPattern(rule.File, node, "osmatch")
+	case "pcre2":
+		return checkPattern(rule.File, node, "pcre2")
+	}
+
+	return nil
+}
+
+func parseDecoderOption(dec *xmlDecoder, node *xmlNode) []rulesetIssue {
+	switch node.Name {
+	case "parent":
+		dec.Parent = node.Text
+		dec.ParentLine = node.Line
+	case "regex", "prematch":
+		return checkPattern(dec.File, node, "osregex")
+	}
+
+	return nil
+}
+
+// Checks a pattern using the syntax from its type attribute
+// or the default syntax for the element
+func checkPattern(path string, node *xmlNode, defaultType string) []rulesetIssue {
+	pattern := node.Text
+	patternType := strings.ToLower(node.Attrs["type"])
+	if patternType == "" {
+		patternType = defaultType
+	}
+
+	var err error
+	var warning string
+	switch patternType {
+	case "osregex":
+		err = validateOSRegex(pattern)
+	case "pcre2":
+		warning, err = validatePCRE2(pattern)
+	case "osmatch":
+		// Literal strings with optional anchors and
+		// alternation, nothing to check
+		return nil
+	default:
+		return []rulesetIssue{{path, node.Line, issueError, "<" + node.Name + "> has an unknown type: " + patternType}}
+	}
+
+	if err != nil {
+		return []rulesetIssue{{path, node.Line, issueError, "Invalid <" + node.Name + "> pattern: " + err.Error()}}
+	}
+	if warning != "" {
+		return []rulesetIssue{{path, node.Line, issueWarning, "<" + node.Name + "> pattern " + warning}}
+	}
+
+	return nil
+}
+
+// Escapes supported by the Wazuh regex (OS_Regex) syntax that
+// are letters. Escaped punctuation always matches literally.
+// See: https://documentation.wazuh.com/current/user-manual/ruleset/ruleset-xml-syntax/regex.html
+var osRegexEscapes = "wWdDsSpt"
+
+// Checks a pattern against the OS_Regex syntax used by default
+// for <regex> and <prematch>
+func validateOSRegex(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("pattern is empty")
+	}
+
+	inGroup := false
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			if i == len(pattern)-1 {
+				return fmt.Errorf("pattern ends with a lone backslash")
+			}
+			i++
+			c := pattern[i]
+			isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+			if isAlnum && !strings.ContainsRune(osRegexEscapes, rune(c)) {
+				return fmt.Errorf("unsupported escape \\%c at position %d", c, i)
+			}
+		case '(':
+			if inGroup {
+				return fmt.Errorf("nested groups are not supported at position %d", i+1)
+			}
+			inGroup = true
+		case ')':
+			if !inGroup {
+				return fmt.Errorf("unmatched ) at position %d", i+1)
+			}
+			inGroup = false
+		}
+	}
+
+	if inGroup {
+		return fmt.Errorf("unclosed (")
+	}
+
+	return nil
+}
+
+// Constructs that PCRE2 supports but Go's regexp package does not
+var pcre2OnlySyntax = regexp.MustCompile(`\(\?<?[=!]|\(\?>|\(\?\||\(\?R|\(\?[+-]?\d|\(\?#|\\[1-9gkKhHvVRX]|[+*?}]\+`)
+
+// Checks a PCRE2 pattern. Patterns are compiled with Go's regexp
+// package which covers most of the PCRE2 syntax. Patterns using
+// features it does not support (lookarounds, backreferences, ...)
+// only get a warning since they cannot be checked.
+func validatePCRE2(pattern string) (string, error) {
+	if pattern == "" {
+		return "", fmt.Errorf("pattern is empty")
+	}
+
+	_, err := regexp.Compile(pattern)
+	if err == nil {
+		return "", nil
+	}
+
+	if pcre2OnlySyntax.MatchString(pattern) {
+		return "uses PCRE2 features that could not be checked", nil
+	}
+
+	return "", err
+}
+
+// Cross-file checks: duplicate IDs, rule levels, references to
+// missing rules and decoders, and overwrites of missing rules
+func analyzeRuleset(files []rulesetFile, refFiles []rulesetFile) rulesetAnalysis {
+	analysis := rulesetAnalysis{NumFiles: len(files)}
+
+	// Rule ID -> first original (not overwrite) definition
+	ruleDefs := map[string]xmlRule{}
+	decoderNames := map[string]struct{}{}
+
+	addDefinitions := func(file rulesetFile) {
+		for _, rule := range file.Rules {
+			if _, exists := ruleDefs[rule.ID]; !exists && !rule.Overwrite {
+				ruleDefs[rule.ID] = rule
+			}
+		}
+		for _, dec := range file.Decoders {
+			decoderNames[dec.Name] = struct{}{}
+		}
+	}
+
+	// Reference files are loaded first so the analyzed
+	// files are reported as the duplicates
+	for _, file := range refFiles {
+		addDefinitions(file)
+	}
+
+	// Definitions from the analyzed files are added as
+	// they are checked to find duplicates
+	for _, file := range files {
+		analysis.Issues = append(analysis.Issues, file.Issues...)
+		analysis.NumRules += len(file.Rules)
+		analysis.NumDecoders += len(file.Decoders)
+
+		for _, rule := range file.Rules {
+			analysis.Issues = append(analysis.Issues, checkRuleAttributes(rule)...)
+
+			if rule.Overwrite || rule.ID == "" {
+				continue
+			}
+
+			if first, exists := ruleDefs[rule.ID]; exists {
+				analysis.Issues = append(analysis.Issues, rulesetIssue{rule.File, rule.Line, issueError, "Duplicate rule ID " + rule.ID + " (first defined at " + first.File + ":" + strconv.Itoa(first.Line) + ")"})
+				continue
+			}
+			ruleDefs[rule.ID] = rule
+		}
+
+		for _, dec := range file.Decoders {
+			decoderNames[dec.Name] = struct{}{}
+		}
+	}
+
+	// References are resolved once every file is loaded
+	// since rules can point to rules in later files
+	for _, file := range files {
+		for _, rule := range file.Rules {
+			if rule.Overwrite && rule.ID != "" {
+				if _, exists := ruleDefs[rule.ID]; !exists {
+					analysis.HasMissingReferences = true
+					analysis.Issues = append(analysis.Issues, rulesetIssue{rule.File, rule.Line, issueError, "Rule " + rule.ID + " has overwrite=\"yes\" but no rule with this ID exists"})
+				}
+			}
+
+			for _, ref := range rule.References {
+				if _, exists := ruleDefs[ref.ID]; !exists {
+					analysis.HasMissingReferences = true
+					analysis.Issues = append(analysis.Issues, rulesetIssue{rule.File, ref.Line, issueError, "Rule " + rule.ID + " <" + ref.Tag + "> references rule " + ref.ID + " which does not exist"})
+				}
+			}
+		}
+
+		for _, dec := range file.Decoders {
+			if dec.Name == "" {
+				analysis.Issues = append(analysis.Issues, rulesetIssue{dec.File, dec.Line, issueError, "Decoder is missing the name attribute"})
+			}
+
+			if dec.Parent == "" {
+				continue
+			}
+			if _, exists := decoderNames[dec.Parent]; !exists {
+				analysis.HasMissingReferences = true
+				analysis.Issues = append(analysis.Issues, rulesetIssue{dec.File, dec.ParentLine, issueError, "Decoder " + dec.Name + " has unknown parent decoder " + dec.Parent})
+			}
+		}
+	}
+
+	sort.SliceStable(analysis.Issues, func(i, j int) bool {
+		if analysis.Issues[i].File != analysis.Issues[j].File {
+			return analysis.Issues[i].File < analysis.Issues[j].File
+		}
+		return analysis.Issues[i].Line < analysis.Issues[j].Line
+	})
+
+	return analysis
+}
+
+// Reuses the rule ID and level checks applied to test definitions
+func checkRuleAttributes(rule xmlRule) []rulesetIssue {
+	var issues []rulesetIssue
+
+	if rule.ID == "" {
+		issues = append(issues, rulesetIssue{rule.File, rule.Line, issueError, "Rule is missing the id attribute"})
+	} else if valid, errors, _ := testdef.IsValidRuleID(rule.ID); !valid {
+		for _, err := range errors {
+			issues = append(issues, rulesetIssue{rule.File, rule.Line, issueError, "Rule " + rule.ID + ": " + err})
+		}
+	}
+
+	if rule.Level == "" {
+		// Overwrites can keep the original level
+		if !rule.Overwrite {
+			issues = append(issues, rulesetIssue{rule.File, rule.Line, issueError, "Rule " + rule.ID + " is missing the level attribute"})
+		}
+	} else if valid, errors, _ := testdef.IsValidRuleLevel(rule.Level); !valid {
+		for _, err := range errors {
+			issues = append(issues, rulesetIssue{rule.File, rule.Line, issueError, "Rule " + rule.ID + ": " + err})
+		}
+	}
+
+	return issues
+}
+
+func printRulesetAnalysis(con console, analysis rulesetAnalysis, hasRefs bool) {
+	for _, issue := range analysis.Issues {
+		if issue.Severity == issueError {
+			con.red(issue.String())
+		} else {
+			con.yellow(issue.String())
+		}
+	}
+	if len(analysis.Issues) > 0 {
+		con.printf("\n")
+	}
+
+	con.boldWhite("Ruleset Analysis:")
+	con.boldWhite("=================\n")
+
+	con.printf("Files: %d\n", analysis.NumFiles)
+	con.printf("Rules: %d\n", analysis.NumRules)
+	con.printf("Decoders: %d\n", analysis.NumDecoders)
+
+	numErrors := analysis.numIssues(issueError)
+	numWarnings := analysis.numIssues(issueWarning)
+	if numErrors > 0 {
+		con.red("Errors: " + strconv.Itoa(numErrors))
+	}
+	if numWarnings > 0 {
+		con.yellow("Warnings: " + strconv.Itoa(numWarnings))
+	}
+	con.printf("\n")
+
+	if analysis.HasMissingReferences && !hasRefs {
+		con.yellow("Only the analyzed files were searched for referenced rules and decoders. Use -ref to load the default ruleset.\n")
+	}
+
+	if numErrors == 0 {
+		con.green("No errors found.")
+	}
+}
+
+// A parsed XML element
+type xmlNode struct {
+	Name     string
+	Attrs    map[string]string
+	Text     string
+	Line     int
+	Children []*xmlNode
+
+	// False for elements that were still open when
+	// an XML error stopped the parser
+	Closed bool
+}
+
+// An XML error and the line it was found on
+type xmlSyntaxError struct {
+	Line int
+	Err  error
+}
+
+func (e *xmlSyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+// Parses a Wazuh XML file into a tree. Wazuh files can have
+// several root elements (e.g. one <group> per rule group or
+// one <decoder> after another) which are all returned. On an
+// XML error the tree read so far is returned with the error.
+func parseXMLTree(content []byte) ([]*xmlNode, error) {
+	lines := newLineIndex(content)
+
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var roots []*xmlNode
+	var stack []*xmlNode
+	var texts []*strings.Builder
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return roots, &xmlSyntaxError{Line: lines.line(decoder.InputOffset()), Err: err}
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{Name: tok.Name.Local, Attrs: map[string]string{}, Line: lines.line(offset)}
+			for _, attr := range tok.Attr {
+				node.Attrs[attr.Name.Local] = attr.Value
+			}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, node)
+			} else {
+				roots = append(roots, node)
+			}
+			stack = append(stack, node)
+			texts = append(texts, &strings.Builder{})
+		case xml.CharData:
+			if len(texts) > 0 {
+				texts[len(texts)-1].Write(tok)
+			}
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			stack[len(stack)-1].Text = strings.TrimSpace(texts[len(texts)-1].String())
+			stack[len(stack)-1].Closed = true
+			stack = stack[:len(stack)-1]
+			texts = texts[:len(texts)-1]
+		}
+	}
+
+	return roots, nil
+}
+
+// Maps byte offsets to 1-based line numbers
+type lineIndex []int64
+
+func newLineIndex(content []byte) lineIndex {
+	index := lineIndex{0}
+	for i, c := range content {
+		if c == '\n' {
+			index = append(index, int64(i+1))
+		}
+	}
+	return index
+}
+
+func (index lineIndex) line(offset int64) int {
+	return sort.Search(len(index), func(i int) bool { return index[i] > offset })
+}
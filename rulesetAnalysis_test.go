@@ -0,0 +1,160 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_validateOSRegex(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr bool
+	}{
+		{name: "Valid literal", pattern: "Failed password", wantErr: false},
+		{name: "Valid groups and classes", pattern: `^User '(\w+)' logged from '(\d+.\d+.\d+.\d+)'`, wantErr: false},
+		{name: "Valid escaped punctuation", pattern: `\(\S+\) \.\$`, wantErr: false},
+		{name: "Valid alternation", pattern: `^(\S+) from|^(\S+) by`, wantErr: false},
+
+		{name: "Invalid empty pattern", pattern: "", wantErr: true},
+		{name: "Invalid unsupported escape", pattern: `\bfoo`, wantErr: true},
+		{name: "Invalid lone backslash", pattern: `foo\`, wantErr: true},
+		{name: "Invalid nested groups", pattern: `(\d+(\w+))`, wantErr: true},
+		{name: "Invalid unclosed group", pattern: `^User (\w+`, wantErr: true},
+		{name: "Invalid unmatched parenthesis", pattern: `\w+)`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := validateOSRegex(tt.pattern); (err != nil) != tt.wantErr {
+				t.Errorf("validateOSRegex() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validatePCRE2(t *testing.T) {
+	tests := []struct {
+		name        string
+		pattern     string
+		wantWarning bool
+		wantErr     bool
+	}{
+		{name: "Valid pattern", pattern: `^user (?P<name>\w+) from [\d.]+$`, wantWarning: false, wantErr: false},
+		{name: "Valid lookbehind", pattern: `(?<=user )\w+`, wantWarning: true, wantErr: false},
+		{name: "Valid backreference", pattern: `(\w)\1`, wantWarning: true, wantErr: false},
+
+		{name: "Invalid empty pattern", pattern: "", wantWarning: false, wantErr: true},
+		{name: "Invalid unclosed class", pattern: `[a-`, wantWarning: false, wantErr: true},
+		{name: "Invalid unclosed group", pattern: `(foo`, wantWarning: false, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			warning, err := validatePCRE2(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validatePCRE2() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (warning != "") != tt.wantWarning {
+				t.Errorf("validatePCRE2() warning = %q, wantWarning %v", warning, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func Test_analyzeRuleset(t *testing.T) {
+	rules := parseRulesetXML("local_rules.xml", []byte(`<group name="local,">
+  <rule id="100001" level="5">
+    <if_sid>5716</if_sid>
+    <description>Valid rule</description>
+  </rule>
+
+  <rule id="100001" level="17">
+    <if_sid>100001, 100099</if_sid>
+    <regex>(\d+(\w+))</regex>
+    <description>Duplicate ID and invalid level</description>
+  </rule>
+
+  <rule id="5710" level="5" overwrite="yes">
+    <description>Overwrites a stock rule</description>
+  </rule>
+
+  <rule id="5999" level="5" overwrite="yes">
+    <if_matched_sid>5716</if_matched_sid>
+    <description>Overwrites a missing rule</description>
+  </rule>
+</group>
+`))
+
+	decoders := parseRulesetXML("local_decoder.xml", []byte(`<decoder name="example">
+  <program_name>^example</program_name>
+</decoder>
+
+<decoder name="example-child">
+  <parent>exampel</parent>
+  <regex>User '(\w+)'</regex>
+</decoder>
+`))
+
+	stock := parseRulesetXML("0095-sshd_rules.xml", []byte(`<group name="sshd,">
+  <rule id="5710" level="5">
+    <description>sshd: Attempt to login using a non-existent user</description>
+  </rule>
+  <rule id="5716" level="5">
+    <description>sshd: authentication failed.</description>
+  </rule>
+</group>
+`))
+
+	analysis := analyzeRuleset([]rulesetFile{decoders, rules}, []rulesetFile{stock})
+
+	var got []string
+	for _, issue := range analysis.Issues {
+		got = append(got, issue.String())
+	}
+
+	want := []string{
+		"local_decoder.xml:6: Decoder example-child has unknown parent decoder exampel",
+		"local_rules.xml:7: Rule 100001: Invalid rule level cannot be greater than 16",
+		"local_rules.xml:7: Duplicate rule ID 100001 (first defined at local_rules.xml:2)",
+		"local_rules.xml:8: Rule 100001 <if_sid> references rule 100099 which does not exist",
+		"local_rules.xml:9: Invalid <regex> pattern: nested groups are not supported at position 5",
+		"local_rules.xml:17: Rule 5999 has overwrite=\"yes\" but no rule with this ID exists",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("analyzeRuleset() issues =\n%v\nwant\n%v", got, want)
+	}
+
+	if analysis.NumFiles != 2 || analysis.NumRules != 4 || analysis.NumDecoders != 2 {
+		t.Errorf("analyzeRuleset() counted %d files, %d rules and %d decoders, want 2, 4 and 2", analysis.NumFiles, analysis.NumRules, analysis.NumDecoders)
+	}
+
+	if !analysis.HasMissingReferences {
+		t.Errorf("analyzeRuleset() HasMissingReferences = false, want true")
+	}
+}
+
+func Test_parseRulesetXMLInvalid(t *testing.T) {
+	// Cut off in the middle of the second rule
+	file := parseRulesetXML("local_rules.xml", []byte(`<group name="local,">
+  <rule id="100001" level="5">
+    <description>Complete rule</description>
+  </rule>
+
+  <rule id="100002" level="5">
+    <description>Cut off rule</description>
+`))
+
+	if len(file.Rules) != 1 || file.Rules[0].ID != "100001" {
+		t.Errorf("parseRulesetXML() rules = %+v, want only 100001", file.Rules)
+	}
+
+	want := []string{"local_rules.xml:8: Invalid XML: XML syntax error on line 8: unexpected EOF"}
+	var got []string
+	for _, issue := range file.Issues {
+		got = append(got, issue.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRulesetXML() issues = %q, want %q", got, want)
+	}
+}
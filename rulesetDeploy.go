@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// The kinds of custom ruleset files that can be deployed.
+// A local ruleset directory uses the same layout as the
+// manager's etc directory:
+//
+//	ruleset/
+//	  rules/local_rules.xml
+//	  decoders/local_decoder.xml
+//	  lists/my-list
+type rulesetFileType struct {
+	Name       string
+	Endpoint   string
+	ManagerDir string
+}
+
+var rulesetFileTypes = []rulesetFileType{
+	{Name: "rules", Endpoint: "rules/files", ManagerDir: "etc/rules"},
+	{Name: "decoders", Endpoint: "decoders/files", ManagerDir: "etc/decoders"},
+	{Name: "lists", Endpoint: "lists/files", ManagerDir: "etc/lists"},
+}
+
+// How the manager picks up a new ruleset
+const (
+	RulesetApplyRestart = "restart"
+	RulesetApplyReload  = "reload"
+)
+
+// A ruleset that has been uploaded to the manager along
+// with everything needed to put the original files back
+type rulesetDeployment struct {
+	ws    *wazuh.WazuhServer
+	apply string
+	wait  time.Duration
+	con   console
+
+	// Type name -> filename -> original contents
+	backups map[string]map[string][]byte
+
+	// Local copy of the backups in case the process
+	// dies before restoring
+	backupDir string
+
+	// Type name -> files uploaded to the manager
+	uploaded map[string][]string
+
+	// Set once the manager was asked to load the
+	// uploaded files
+	applied bool
+
+	restoreOnce sync.Once
+	restoreErr  error
+}
+
+func newRulesetDeployment(ws *wazuh.WazuhServer, apply string, wait time.Duration, con console) *rulesetDeployment {
+	return &rulesetDeployment{
+		ws:       ws,
+		apply:    apply,
+		wait:     wait,
+		con:      con,
+		backups:  map[string]map[string][]byte{},
+		uploaded: map[string][]string{},
+	}
+}
+
+// Back up the manager's custom rules, decoders and lists, upload
+// the files from rulesetDir and wait for the manager to load
+// them. If any step fails or ctx is cancelled the originals
+// are restored before returning the error.
+func (rd *rulesetDeployment) deploy(ctx context.Context, rulesetDir string) error {
+	localFiles, err := readLocalRuleset(rulesetDir)
+	if err != nil {
+		return err
+	}
+
+	rd.con.white("Backing up manager ruleset...")
+	if err := rd.backup(); err != nil {
+		os.RemoveAll(rd.backupDir)
+		return err
+	}
+	rd.con.white("Saved backup to: " + rd.backupDir)
+
+	rd.con.white("Uploading ruleset from: " + rulesetDir)
+	numUploaded := 0
+	for _, fileType := range rulesetFileTypes {
+		files := localFiles[fileType.Name]
+
+		filenames := make([]string, 0, len(files))
+		for filename := range files {
+			filenames = append(filenames, filename)
+		}
+		sort.Strings(filenames)
+
+		for _, filename := range filenames {
+			if err := ctx.Err(); err != nil {
+				return rd.abort(err)
+			}
+
+			// Record the upload first so a partially
+			// written file is still restored
+			rd.uploaded[fileType.Name] = append(rd.uploaded[fileType.Name], filename)
+
+			err := rd.ws.PutRulesetFile(fileType.Endpoint, filename, files[filename])
+			if err != nil {
+				return rd.abort(err)
+			}
+			numUploaded++
+		}
+	}
+
+	if err := rd.load(ctx); err != nil {
+		return rd.abort(err)
+	}
+
+	rd.con.green("Deployed " + strconv.Itoa(numUploaded) + " ruleset files to manager.")
+	rd.con.printf("\n")
+
+	return nil
+}
+
+// Read the files to deploy from the rules, decoders and lists
+// subdirectories. Returns type name -> filename -> contents.
+func readLocalRuleset(rulesetDir string) (map[string]map[string][]byte, error) {
+	isDir, err := testdef.IsDir(rulesetDir)
+	if err != nil {
+		return nil, err
+	}
+	if !isDir {
+		return nil, fmt.Errorf("ruleset directory %s does not exist", rulesetDir)
+	}
+
+	localFiles := map[string]map[string][]byte{}
+	numFiles := 0
+	for _, fileType := range rulesetFileTypes {
+		localFiles[fileType.Name] = map[string][]byte{}
+
+		typeDir := filepath.Join(rulesetDir, fileType.Name)
+		exists, err := testdef.FileExists(typeDir)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+
+		entries, err := os.ReadDir(typeDir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+
+			// Rules and decoders must be XML files
+			if fileType.Name != "lists" && filepath.Ext(entry.Name()) != ".xml" {
+				continue
+			}
+
+			content, err := os.ReadFile(filepath.Join(typeDir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+
+			localFiles[fileType.Name][entry.Name()] = content
+			numFiles++
+		}
+	}
+
+	if numFiles == 0 {
+		return nil, fmt.Errorf("no rules, decoders or lists found in %s", rulesetDir)
+	}
+
+	return localFiles, nil
+}
+
+// Download every custom ruleset file from the manager and
+// keep a copy on disk
+func (rd *rulesetDeployment) backup() error {
+	backupDir, err := os.MkdirTemp("", "wazuhtest-ruleset-backup-")
+	if err != nil {
+		return fmt.Errorf("error creating backup directory: %s", err)
+	}
+	rd.backupDir = backupDir
+
+	for _, fileType := range rulesetFileTypes {
+		rd.backups[fileType.Name] = map[string][]byte{}
+
+		filenames, err := rd.ws.ListRulesetFiles(fileType.Endpoint, fileType.ManagerDir)
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Join(backupDir, fileType.Name), 0700); err != nil {
+			return fmt.Errorf("error creating backup directory: %s", err)
+		}
+
+		for _, filename := range filenames {
+			content, err := rd.ws.GetRulesetFile(fileType.Endpoint, filename)
+			if err != nil {
+				return err
+			}
+
+			rd.backups[fileType.Name][filename] = content
+
+			err = os.WriteFile(filepath.Join(backupDir, fileType.Name, filename), content, 0600)
+			if err != nil {
+				return fmt.Errorf("error saving backup of %s: %s", filename, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Validate the configuration and get the manager to load
+// the ruleset that is currently on disk
+func (rd *rulesetDeployment) load(ctx context.Context) error {
+	rd.con.white("Validating manager configuration...")
+	if err := rd.ws.ValidateConfiguration(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rd.applied = true
+
+	switch rd.apply {
+	case RulesetApplyReload:
+		rd.con.white("Reloading analysisd...")
+		if err := rd.ws.ReloadAnalysisd(); err != nil {
+			return err
+		}
+	default:
+		rd.con.white("Restarting manager...")
+		if err := rd.ws.RestartManager(); err != nil {
+			return err
+		}
+	}
+
+	rd.con.white("Waiting for analysisd...")
+	return rd.ws.WaitForAnalysisd(ctx, rd.wait)
+}
+
+// Restore the originals after a failed deployment and
+// return the deployment error
+func (rd *rulesetDeployment) abort(err error) error {
+	if restoreErr := rd.restore(); restoreErr != nil {
+		return fmt.Errorf("%s (restoring the original ruleset also failed: %s)", err, restoreErr)
+	}
+
+	return err
+}
+
+// Put the original files back on the manager and reload them.
+// This is safe to call more than once and from several
+// goroutines; only the first call restores.
+func (rd *rulesetDeployment) restore() error {
+	rd.restoreOnce.Do(func() {
+		rd.restoreErr = rd.restoreFiles()
+	})
+
+	return rd.restoreErr
+}
+
+func (rd *rulesetDeployment) restoreFiles() error {
+	numUploaded := 0
+	for _, filenames := range rd.uploaded {
+		numUploaded += len(filenames)
+	}
+
+	// Nothing on the manager was changed
+	if numUploaded == 0 {
+		os.RemoveAll(rd.backupDir)
+		return nil
+	}
+
+	rd.con.white("Restoring manager ruleset...")
+
+	// The token from the start of the run may have expired
+	// by now. The lifetime is set on the manager so always
+	// get a new one.
+	if err := rd.ws.RefreshAuthToken(0); err != nil {
+		return fmt.Errorf("error renewing API token: %s. Original files are saved in: %s", err, rd.backupDir)
+	}
+
+	var failures []string
+	for _, fileType := range rulesetFileTypes {
+		for _, filename := range rd.uploaded[fileType.Name] {
+			original, existed := rd.backups[fileType.Name][filename]
+
+			var err error
+			if existed {
+				err = rd.ws.PutRulesetFile(fileType.Endpoint, filename, original)
+			} else {
+				err = rd.ws.DeleteRulesetFile(fileType.Endpoint, filename)
+			}
+
+			if err != nil {
+				failures = append(failures, err.Error())
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%s. Original files are saved in: %s", strings.Join(failures, ", "), rd.backupDir)
+	}
+
+	// The manager is still running the original ruleset
+	// if it never loaded the uploaded files. This runs after
+	// an interrupt so it cannot be cancelled.
+	if rd.applied {
+		if err := rd.load(context.Background()); err != nil {
+			return fmt.Errorf("%s. Original files are saved in: %s", err, rd.backupDir)
+		}
+	}
+
+	os.RemoveAll(rd.backupDir)
+	rd.con.green("Restored manager ruleset.")
+
+	return nil
+}
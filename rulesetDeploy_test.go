@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// Keeps the custom ruleset files of a manager in memory
+type fakeRulesetManager struct {
+	lock sync.Mutex
+
+	// Type name -> filename -> contents
+	files map[string]map[string]string
+
+	// Uploads of this file fail with a failed_items error
+	failUpload string
+
+	// Status of the validation node
+	validation string
+
+	// Called for every upload before it is stored
+	onUpload func(filename string)
+
+	// Every request that changed the manager,
+	// e.g. "PUT rules/local_rules.xml"
+	changes []string
+
+	// Number of tokens issued. Tokens up to expired are
+	// rejected like the API does once they time out.
+	tokens  int
+	expired int
+}
+
+func newFakeRulesetManager(t *testing.T, manager *fakeRulesetManager) *wazuh.WazuhServer {
+	server := httptest.NewServer(manager)
+	t.Cleanup(server.Close)
+
+	ws, err := wazuh.NewWazuhServerFromURL(strings.Replace(server.URL, "http://", "http://wazuh:wazuh@", 1), 5)
+	if err != nil {
+		t.Fatalf("Failed to connect to fake manager: %v", err)
+	}
+	return ws
+}
+
+func (manager *fakeRulesetManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	reply := func(result interface{}) {
+		json.NewEncoder(w).Encode(result)
+	}
+	ok := map[string]interface{}{"data": map[string]interface{}{"affected_items": []interface{}{}}, "error": 0}
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if path == "security/user/authenticate" {
+		manager.tokens++
+		reply(map[string]interface{}{"data": map[string]interface{}{"token": "token" + strconv.Itoa(manager.tokens)}, "error": 0})
+		return
+	}
+
+	token, _ := strconv.Atoi(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer token"))
+	if token <= manager.expired {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case path == "manager/configuration/validation":
+		node := map[string]interface{}{"name": "node01", "status": manager.validation, "details": []string{"Error in rules"}}
+		reply(map[string]interface{}{"data": map[string]interface{}{"affected_items": []interface{}{node}}, "error": 0})
+		return
+	case path == "manager/restart":
+		manager.changes = append(manager.changes, "PUT manager/restart")
+		reply(ok)
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || parts[1] != "files" || manager.files[parts[0]] == nil {
+		http.NotFound(w, r)
+		return
+	}
+	files := manager.files[parts[0]]
+
+	if len(parts) == 2 {
+		items := []interface{}{}
+		for filename := range files {
+			items = append(items, map[string]interface{}{"filename": filename})
+		}
+		reply(map[string]interface{}{"data": map[string]interface{}{"affected_items": items, "total_affected_items": len(items)}, "error": 0})
+		return
+	}
+
+	filename := parts[2]
+	switch r.Method {
+	case http.MethodGet:
+		io.WriteString(w, files[filename])
+	case http.MethodPut:
+		manager.changes = append(manager.changes, "PUT "+parts[0]+"/"+filename)
+		if manager.onUpload != nil {
+			manager.onUpload(filename)
+		}
+		if filename == manager.failUpload {
+			// The API reports failed items with a 200
+			failed := map[string]interface{}{"error": map[string]interface{}{"code": 1113, "message": "XML syntax error"}}
+			reply(map[string]interface{}{"data": map[string]interface{}{"failed_items": []interface{}{failed}, "total_failed_items": 1}, "message": "Could not upload file", "error": 1})
+			return
+		}
+		content, _ := io.ReadAll(r.Body)
+		files[filename] = string(content)
+		reply(ok)
+	case http.MethodDelete:
+		manager.changes = append(manager.changes, "DELETE "+parts[0]+"/"+filename)
+		delete(files, filename)
+		reply(ok)
+	}
+}
+
+func (manager *fakeRulesetManager) getFiles() map[string]map[string]string {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	copied := map[string]map[string]string{}
+	for name, files := range manager.files {
+		copied[name] = map[string]string{}
+		for filename, content := range files {
+			copied[name][filename] = content
+		}
+	}
+	return copied
+}
+
+func writeLocalRuleset(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create ruleset directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write ruleset file: %v", err)
+		}
+	}
+	return dir
+}
+
+func Test_deployRulesetAbort(t *testing.T) {
+	original := func() map[string]map[string]string {
+		return map[string]map[string]string{
+			"rules":    {"local_rules.xml": "original rules"},
+			"decoders": {"local_decoder.xml": "original decoder"},
+			"lists":    {},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		local       map[string]string
+		failUpload  string
+		validation  string
+		wantErr     string
+		wantChanges []string
+	}{
+		{
+			name: "Invalid upload fails partway",
+			local: map[string]string{
+				"decoders/local_decoder.xml": "new decoder",
+				"rules/local_rules.xml":      "new rules",
+				"rules/z_rules.xml":          "broken rules",
+			},
+			failUpload: "z_rules.xml",
+			validation: "OK",
+			wantErr:    "error uploading z_rules.xml: XML syntax error",
+			wantChanges: []string{
+				// Deploy
+				"PUT rules/local_rules.xml", "PUT rules/z_rules.xml",
+				// Restore
+				"PUT rules/local_rules.xml", "DELETE rules/z_rules.xml",
+			},
+		},
+		{
+			name: "Invalid new file removed after validation fails",
+			local: map[string]string{
+				"rules/new_rules.xml": "new rules",
+			},
+			validation: "KO",
+			wantErr:    "invalid configuration: [Error in rules]",
+			wantChanges: []string{
+				"PUT rules/new_rules.xml",
+				"DELETE rules/new_rules.xml",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := &fakeRulesetManager{files: original(), failUpload: tt.failUpload, validation: tt.validation}
+			ws := newFakeRulesetManager(t, manager)
+
+			rd := newRulesetDeployment(ws, RulesetApplyRestart, time.Second, console{out: io.Discard})
+			err := rd.deploy(context.Background(), writeLocalRuleset(t, tt.local))
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("deploy() error = %v, want %q", err, tt.wantErr)
+			}
+
+			if got := manager.getFiles(); !reflect.DeepEqual(got, original()) {
+				t.Errorf("deploy() left manager files = %v, want %v", got, original())
+			}
+			if !reflect.DeepEqual(manager.changes, tt.wantChanges) {
+				t.Errorf("deploy() changes = %q, want %q", manager.changes, tt.wantChanges)
+			}
+			if _, err := os.Stat(rd.backupDir); !os.IsNotExist(err) {
+				t.Errorf("deploy() kept backup directory %s after restoring", rd.backupDir)
+			}
+		})
+	}
+}
+
+// An interrupt during the upload cancels the deploy, which
+// restores, while the deferred restore in run fires too
+func Test_deployRulesetRestoreOnce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	manager := &fakeRulesetManager{
+		files:      map[string]map[string]string{"rules": {"a_rules.xml": "original"}, "decoders": {}, "lists": {}},
+		validation: "OK",
+		onUpload: func(filename string) {
+			if filename == "b_rules.xml" {
+				cancel()
+			}
+		},
+	}
+	ws := newFakeRulesetManager(t, manager)
+	local := writeLocalRuleset(t, map[string]string{
+		"rules/a_rules.xml": "new a",
+		"rules/b_rules.xml": "new b",
+		"rules/c_rules.xml": "new c",
+	})
+
+	rd := newRulesetDeployment(ws, RulesetApplyRestart, time.Second, console{out: io.Discard})
+	if err := rd.deploy(ctx, local); !errors.Is(err, context.Canceled) {
+		t.Fatalf("deploy() error = %v, want %v", err, context.Canceled)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := rd.restore(); err != nil {
+				t.Errorf("restore() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := []string{
+		"PUT rules/a_rules.xml", "PUT rules/b_rules.xml",
+		"PUT rules/a_rules.xml", "DELETE rules/b_rules.xml",
+	}
+	if !reflect.DeepEqual(manager.changes, want) {
+		t.Errorf("restore() changes = %q, want %q", manager.changes, want)
+	}
+	if got := manager.getFiles()["rules"]; !reflect.DeepEqual(got, map[string]string{"a_rules.xml": "original"}) {
+		t.Errorf("restore() left rules = %v", got)
+	}
+}
+
+// The token expires during the deploy, as it would on a run
+// longer than the token lifetime, and the restore gets a new
+// one instead of failing with a 401
+func Test_deployRulesetTokenExpired(t *testing.T) {
+	var manager *fakeRulesetManager
+	manager = &fakeRulesetManager{
+		files:      map[string]map[string]string{"rules": {"a_rules.xml": "original"}, "decoders": {}, "lists": {}},
+		validation: "OK",
+		onUpload: func(filename string) {
+			if filename == "b_rules.xml" {
+				manager.expired = manager.tokens
+			}
+		},
+	}
+	ws := newFakeRulesetManager(t, manager)
+	local := writeLocalRuleset(t, map[string]string{
+		"rules/a_rules.xml": "new a",
+		"rules/b_rules.xml": "new b",
+		"rules/c_rules.xml": "new c",
+	})
+
+	rd := newRulesetDeployment(ws, RulesetApplyRestart, time.Second, console{out: io.Discard})
+	if err := rd.deploy(context.Background(), local); err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Fatalf("deploy() error = %v, want an authentication error", err)
+	}
+
+	// c_rules.xml was rejected but is still removed on restore
+	want := []string{
+		"PUT rules/a_rules.xml", "PUT rules/b_rules.xml",
+		"PUT rules/a_rules.xml", "DELETE rules/b_rules.xml", "DELETE rules/c_rules.xml",
+	}
+	if !reflect.DeepEqual(manager.changes, want) {
+		t.Errorf("deploy() changes = %q, want %q", manager.changes, want)
+	}
+	if got := manager.getFiles()["rules"]; !reflect.DeepEqual(got, map[string]string{"a_rules.xml": "original"}) {
+		t.Errorf("deploy() left rules = %v", got)
+	}
+}
+
+func Test_cancelOnInterrupt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stop := cancelOnInterrupt(console{out: io.Discard}, cancel)
+	defer stop()
+
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Failed to find own process: %v", err)
+	}
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Skipf("Interrupts cannot be sent on this platform: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("cancelOnInterrupt() did not cancel the run")
+	}
+}
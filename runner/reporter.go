@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// Receives the events of a test run. Events are sent from a
+// single goroutine so reporters do not need any locking.
+type Reporter interface {
+	// The connection to the manager was verified
+	Connection(info wazuh.ManagerInfo)
+
+	// Issues found while the test tree is loaded and
+	// warnings about the run as a whole
+	testdef.LoadReporter
+
+	// Every test is loaded and about to run
+	RunStart(testDirs []TestDir)
+
+	// A test completed. Tests complete in any order.
+	TestResult(test testdef.LogTest, result Result)
+
+	// The results of a directory. Sent once every test has
+	// completed, in the order the test tree was walked.
+	DirectoryResults(dir TestDir)
+
+	// The run is over
+	Summary(testDirs []TestDir, summary Summary)
+}
+
+// Sends every event to each of the reporters in order
+type MultiReporter []Reporter
+
+func (reporters MultiReporter) Connection(info wazuh.ManagerInfo) {
+	for _, reporter := range reporters {
+		reporter.Connection(info)
+	}
+}
+
+func (reporters MultiReporter) LoadIssue(issue testdef.LoadIssue) {
+	for _, reporter := range reporters {
+		reporter.LoadIssue(issue)
+	}
+}
+
+func (reporters MultiReporter) Warning(message string) {
+	for _, reporter := range reporters {
+		reporter.Warning(message)
+	}
+}
+
+func (reporters MultiReporter) RunStart(testDirs []TestDir) {
+	for _, reporter := range reporters {
+		reporter.RunStart(testDirs)
+	}
+}
+
+func (reporters MultiReporter) TestResult(test testdef.LogTest, result Result) {
+	for _, reporter := range reporters {
+		reporter.TestResult(test, result)
+	}
+}
+
+func (reporters MultiReporter) DirectoryResults(dir TestDir) {
+	for _, reporter := range reporters {
+		reporter.DirectoryResults(dir)
+	}
+}
+
+func (reporters MultiReporter) Summary(testDirs []TestDir, summary Summary) {
+	for _, reporter := range reporters {
+		reporter.Summary(testDirs, summary)
+	}
+}
+
+// Ignores every event. Embedded by reporters that only
+// handle a few of them.
+type NopReporter struct{}
+
+func (NopReporter) Connection(info wazuh.ManagerInfo)              {}
+func (NopReporter) LoadIssue(issue testdef.LoadIssue)              {}
+func (NopReporter) Warning(message string)                         {}
+func (NopReporter) RunStart(testDirs []TestDir)                    {}
+func (NopReporter) TestResult(test testdef.LogTest, result Result) {}
+func (NopReporter) DirectoryResults(dir TestDir)                   {}
+func (NopReporter) Summary(testDirs []TestDir, summary Summary)    {}
@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// Records the name of every event it receives
+type recordingReporter struct {
+	events []string
+}
+
+func (r *recordingReporter) Connection(info wazuh.ManagerInfo) {
+	r.events = append(r.events, "Connection "+info.APIVersion)
+}
+
+func (r *recordingReporter) LoadIssue(issue testdef.LoadIssue) {
+	r.events = append(r.events, "LoadIssue "+issue.GetName())
+}
+
+func (r *recordingReporter) Warning(message string) {
+	r.events = append(r.events, "Warning")
+}
+
+func (r *recordingReporter) RunStart(testDirs []TestDir) {
+	r.events = append(r.events, "RunStart")
+}
+
+func (r *recordingReporter) TestResult(test testdef.LogTest, result Result) {
+	r.events = append(r.events, "TestResult "+test.ID+" "+result.GetStatus())
+}
+
+func (r *recordingReporter) DirectoryResults(dir TestDir) {
+	r.events = append(r.events, "DirectoryResults "+filepath.Base(dir.Path))
+}
+
+func (r *recordingReporter) Summary(testDirs []TestDir, summary Summary) {
+	r.events = append(r.events, "Summary")
+}
+
+func Test_RunTestGroupReporters(t *testing.T) {
+	root := t.TempDir()
+	definition := `{"Tests": [
+		{"Version": "0.1", "TestDescription": "Invalid user", "Format": "syslog", "Sequence": [{"Log": "Mar  5 13:49:34 host sshd[1602]: Invalid user bob", "RuleID": "5710"}]},
+		{"Version": "0.1", "RuleID": "5710", "RuleLevel": "5", "Format": "syslog", "LogFilePath": "missing.txt"}
+	]}`
+	if err := os.WriteFile(filepath.Join(root, "test_ssh.json"), []byte(definition), 0600); err != nil {
+		t.Fatalf("Failed to write test definition: %v", err)
+	}
+
+	first, second := &recordingReporter{}, &recordingReporter{}
+	if _, err := RunTestGroup(context.Background(), &fakeFrequencyBackend{}, root, nil, 1, MultiReporter{first, second}); err != nil {
+		t.Fatalf("RunTestGroup() error = %v", err)
+	}
+
+	want := []string{
+		"LoadIssue " + filepath.Join(root, "test_ssh.json") + ": Test #2 (RuleID: 5710)",
+		"RunStart",
+		"TestResult test_ssh.json#1 passed",
+		"DirectoryResults " + filepath.Base(root),
+	}
+	if !reflect.DeepEqual(first.events, want) {
+		t.Errorf("RunTestGroup() events = %q, want %q", first.events, want)
+	}
+	if !reflect.DeepEqual(second.events, first.events) {
+		t.Errorf("RunTestGroup() second reporter events = %q, want %q", second.events, first.events)
+	}
+}
@@ -0,0 +1,385 @@
+// Package runner runs the tests of a test tree against a
+// logtest backend and returns the result of every test.
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// Here is an example structure of:
+// rootTestDir/
+//
+//	test_3.json
+//	test_3.log
+//	- testGroup1/
+//	  test_1.json
+//	  test_1.log
+//	  test_2.json
+//	  test_2.log
+//
+// Each directory groups tests together. Each file that matches the format
+// test_*.json is a test definition/declaration. Each test definition file
+// is a list of json logTest objects. Each test will have a corresponding
+// log file that is the raw log data that will be sent to Wazuh for processing.
+// This can be named anything except test_*.json as it's name defined in the
+// test definition file.
+//
+// Groups can be nested to any depth. The test runner will recursively search
+// for test definition files and log files in the root directory and all
+// subdirectories.
+//
+// The loaded test directories are returned with the
+// result of every test filled in. Progress and results
+// are sent to the reporter as the run goes on. If ctx is
+// cancelled the tests that were not sent yet are reported
+// as cancelled and the error of ctx is returned.
+func RunTestGroup(ctx context.Context, backend wazuh.LogTestBackend, rootTestDir string, filter *regexp.Regexp, numThreads int, reporter Reporter) ([]TestDir, error) {
+
+	// Check if rootTestDir exists
+	exists, err := testdef.FileExists(rootTestDir)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("root test directory does not exist")
+	}
+
+	// Check if rootTestDir is a directory
+	isDir, err := testdef.IsDir(rootTestDir)
+	if err != nil {
+		return nil, err
+	}
+	if !isDir {
+		return nil, errors.New("root test directory is not a directory")
+	}
+
+	// Load the whole test tree up front so that
+	// every test can be scheduled on the same
+	// pool of workers regardless of its directory.
+	testDirs, err := CollectTestDirs(rootTestDir, filter, reporter)
+	if err != nil {
+		return nil, err
+	}
+
+	reporter.RunStart(testDirs)
+	RunTestPool(ctx, backend, testDirs, numThreads, reporter.TestResult)
+	if err := ctx.Err(); err != nil {
+		return testDirs, err
+	}
+
+	// Report the results one directory at a time in
+	// the order the directories were walked. This keeps
+	// the output identical between runs no matter which
+	// order the workers finished in.
+	for _, testDir := range testDirs {
+		reporter.DirectoryResults(testDir)
+	}
+
+	return testDirs, nil
+}
+
+// A directory of the test tree along with the
+// results of its tests
+type TestDir struct {
+	testdef.TestDir
+
+	// Results[i] is the result of Tests[i]
+	Results []Result
+}
+
+// Loads the test tree like testdef.CollectTestDirs. The
+// results are filled in once the tests are run.
+func CollectTestDirs(rootDir string, filter *regexp.Regexp, reporter testdef.LoadReporter) ([]TestDir, error) {
+	loaded, err := testdef.CollectTestDirs(rootDir, filter, reporter)
+	if err != nil {
+		return nil, err
+	}
+
+	testDirs := make([]TestDir, len(loaded))
+	for i, dir := range loaded {
+		testDirs[i] = TestDir{TestDir: dir}
+	}
+
+	return testDirs, nil
+}
+
+// The number of tests of a run by outcome. Expected
+// failures are not counted as failed.
+type Summary struct {
+	Total            int
+	Failed           int
+	Warned           int
+	Skipped          int
+	ExpectedFailures int
+	UnexpectedPasses int
+}
+
+// Count the tests of a run by outcome
+func SummarizeResults(testDirs []TestDir) Summary {
+	var summary Summary
+
+	for _, testDir := range testDirs {
+		for _, result := range testDir.Results {
+			summary.Total++
+			switch result.GetStatus() {
+			case StatusSkipped, StatusCancelled:
+				summary.Skipped++
+			case StatusExpectedFail:
+				summary.ExpectedFailures++
+			case StatusUnexpectedPass:
+				summary.UnexpectedPasses++
+			case StatusFailed:
+				summary.Failed++
+			}
+			if len(result.Warnings) > 0 {
+				summary.Warned++
+			}
+		}
+	}
+
+	return summary
+}
+
+// The outcome of running a single LogTest
+type Result struct {
+	Passed   bool
+	Errors   []string
+	Warnings []string
+
+	// The response returned by the Wazuh server. This
+	// is empty if the test failed before getting one.
+	// For sequence tests this is the last response.
+	Response wazuh.Response
+
+	// Every response of a sequence test in the order
+	// the events were sent
+	Steps []wazuh.Response
+
+	// Time spent waiting for the backend. For sequence
+	// tests this is the total of every event.
+	Duration time.Duration
+
+	// The events as they were sent, after placeholders
+	// and timestamps were filled in
+	Events []string
+
+	// Set from the markers of the test. An expected
+	// failure did not pass and an unexpected pass did.
+	Skipped        bool
+	ExpectedFail   bool
+	UnexpectedPass bool
+
+	// The run was cancelled before the test started
+	Cancelled bool
+}
+
+// Outcomes of a test as reported
+const (
+	StatusPassed         = "passed"
+	StatusFailed         = "failed"
+	StatusSkipped        = "skipped"
+	StatusExpectedFail   = "expected-fail"
+	StatusUnexpectedPass = "unexpected-pass"
+	StatusCancelled      = "cancelled"
+)
+
+func (result Result) GetStatus() string {
+	switch {
+	case result.Cancelled:
+		return StatusCancelled
+	case result.Skipped:
+		return StatusSkipped
+	case result.ExpectedFail:
+		return StatusExpectedFail
+	case result.UnexpectedPass:
+		return StatusUnexpectedPass
+	case !result.Passed:
+		return StatusFailed
+	}
+	return StatusPassed
+}
+
+// Every response the test received
+func (result Result) GetResponses() []wazuh.Response {
+	if len(result.Steps) > 0 {
+		return result.Steps
+	}
+	return []wazuh.Response{result.Response}
+}
+
+// A unit of work for the test pool. It points back
+// to the test's position in the test tree so the
+// result can be stored without any shared counters.
+type testJob struct {
+	dirIndex  int
+	testIndex int
+	logTest   testdef.LogTest
+	result    Result
+}
+
+// Run every test in the tree on a single pool of numThreads
+// workers. Workers only ever send their results over a channel
+// and a single goroutine collects them, so no test state is
+// shared between goroutines.
+//
+// The results are stored in the Results of each TestDir.
+// When set, completed is called by the collector for every
+// test as it finishes. Once ctx is cancelled no more tests
+// are started and the tests that were not started complete
+// with a cancelled result, so every test is still reported.
+func RunTestPool(ctx context.Context, backend wazuh.LogTestBackend, testDirs []TestDir, numThreads int, completed func(testdef.LogTest, Result)) {
+	for i := range testDirs {
+		testDirs[i].Results = make([]Result, len(testDirs[i].Tests))
+	}
+
+	if numThreads < 1 {
+		numThreads = 1
+	}
+
+	jobs := make(chan testJob)
+	finished := make(chan testJob)
+
+	// Start the workers
+	var workers sync.WaitGroup
+	for i := 0; i < numThreads; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				job.result = Run(ctx, backend, job.logTest)
+				finished <- job
+			}
+		}()
+	}
+
+	// Single collector that owns the results
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for job := range finished {
+			testDirs[job.dirIndex].Results[job.testIndex] = job.result
+			if completed != nil {
+				completed(job.logTest, job.result)
+			}
+		}
+	}()
+
+	for dirIndex, testDir := range testDirs {
+		for testIndex, logTest := range testDir.Tests {
+			job := testJob{dirIndex: dirIndex, testIndex: testIndex, logTest: logTest}
+
+			// A select picks at random when a worker is
+			// also ready so check first
+			if ctx.Err() == nil {
+				select {
+				case jobs <- job:
+					continue
+				case <-ctx.Done():
+				}
+			}
+
+			job.result = Result{Cancelled: true}
+			finished <- job
+		}
+	}
+	close(jobs)
+
+	workers.Wait()
+	close(finished)
+	<-collected
+}
+
+// Runs a single test of any kind. The markers of the test
+// are applied to the result. Requests still waiting on the
+// backend are cancelled with ctx.
+func Run(ctx context.Context, backend wazuh.LogTestBackend, logTest testdef.LogTest) Result {
+	var result Result
+	switch {
+	case logTest.Skip != "":
+		return Result{Passed: true, Skipped: true}
+	case logTest.IsSequence():
+		result = runSequenceTest(ctx, backend, logTest)
+	default:
+		result = runTest(ctx, backend, logTest)
+	}
+
+	if logTest.ExpectFail != "" {
+		result.ExpectedFail = !result.Passed
+		result.UnexpectedPass = result.Passed
+	}
+
+	return result
+}
+
+// This function will run a single test and return back the pass/fail
+// and any errors that occurred during the test along with the
+// response from the Wazuh server and how long it took.
+func runTest(ctx context.Context, backend wazuh.LogTestBackend, logTest testdef.LogTest) Result {
+	var result Result
+
+	// Load the log file
+	logData, err := os.ReadFile(logTest.GetLogFilePath())
+	if err != nil {
+		result.Errors = append(result.Errors, "Error opening log file: "+err.Error())
+		return result
+	}
+
+	// Fill in the placeholders of parameterized tests
+	event := logTest.FillVars(string(logData))
+
+	event, logTest.Predecoder, result.Warnings = testdef.ApplyTimestamp(logTest.Timestamp, event, logTest.Predecoder)
+	result.Events = []string{event}
+
+	// Send the log to the backend
+	response, backendWarnings, duration, err := sendTimedLogTestEvent(ctx, backend, event, logTest.GetFormat(), logTest.GetTimeout())
+	result.Warnings = append(result.Warnings, backendWarnings...)
+	result.Duration = duration
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	result.Response = response
+
+	// Validate the response
+	passed, resErrors, resWarnings := validateLogTestResponse(logTest, response)
+	if !passed {
+		result.Errors = append(result.Errors, resErrors...)
+		result.Warnings = append(result.Warnings, resWarnings...)
+	}
+	result.Passed = passed
+
+	return result
+}
+
+// Sends a single event and parses the result into a Response
+func sendLogTestEvent(ctx context.Context, sender wazuh.LogTestSender, event string, logFormat string) (wazuh.Response, []string, error) {
+	result, warnings, err := sender.SendLogTest(ctx, event, logFormat)
+	if err != nil {
+		return wazuh.Response{}, warnings, errors.New("Error sending request: " + err.Error())
+	}
+
+	// Convert result map to JSON bytes
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		return wazuh.Response{}, warnings, errors.New("Error marshalling Wazuh server result map to JSON: " + err.Error())
+	}
+
+	// Unmarshal JSON bytes into the Response struct
+	var response wazuh.Response
+	err = json.Unmarshal(jsonBytes, &response)
+	if err != nil {
+		return wazuh.Response{}, warnings, errors.New("Error unmarshalling Wazuh server response JSON to Response struct: " + err.Error())
+	}
+	response.Raw = result
+
+	return response, warnings, nil
+}
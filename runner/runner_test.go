@@ -0,0 +1,165 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+func Test_RunTestPoolMarkers(t *testing.T) {
+	login := "Mar  5 13:49:34 ip-10-0-0-10 sshd[1602]: Invalid user bob from 192.168.1.4 port 59528"
+	sequence := func(ruleID string) []testdef.SequenceStep {
+		return []testdef.SequenceStep{{Log: login, RuleID: ruleID}}
+	}
+
+	testDirs := []TestDir{{TestDir: testdef.TestDir{Path: "tests", Tests: []testdef.LogTest{
+		{ID: "fixed", Sequence: sequence("5710"), ExpectFail: "ticket-1"},
+		{ID: "broken", Sequence: sequence("100200"), ExpectFail: "ticket-2"},
+		{ID: "skipped", Sequence: sequence("5710"), Skip: "flaky"},
+		{ID: "failed", Sequence: sequence("100200")},
+		{ID: "passed", Sequence: sequence("5710")},
+	}}}}
+
+	RunTestPool(context.Background(), &fakeFrequencyBackend{}, testDirs, 2, nil)
+
+	results := testDirs[0].Results
+	if !results[0].UnexpectedPass || results[0].ExpectedFail {
+		t.Errorf("RunTestPool() fixed = %+v, want an unexpected pass", results[0])
+	}
+	if !results[1].ExpectedFail || results[1].Passed {
+		t.Errorf("RunTestPool() broken = %+v, want an expected failure", results[1])
+	}
+	if !results[2].Skipped || results[2].Response.Data.Output.Rule.ID != "" {
+		t.Errorf("RunTestPool() skipped = %+v, want skipped without sending", results[2])
+	}
+
+	want := Summary{Total: 5, Failed: 1, Skipped: 1, ExpectedFailures: 1, UnexpectedPasses: 1}
+	if got := SummarizeResults(testDirs); got != want {
+		t.Errorf("summarizeResults() = %+v, want %+v", got, want)
+	}
+}
+
+// Fires the rule named in the event, e.g. "rule 100200",
+// after a delay that makes later events finish first
+type echoBackend struct {
+	delay func(ruleID string) time.Duration
+}
+
+func (backend echoBackend) NewSession() wazuh.LogTestSession {
+	return echoSession{backend}
+}
+
+func (backend echoBackend) SendLogTest(ctx context.Context, event string, logFormat string) (map[string]interface{}, []string, error) {
+	ruleID := strings.TrimPrefix(event, "rule ")
+	time.Sleep(backend.delay(ruleID))
+	rule := map[string]interface{}{"id": ruleID, "level": 3, "description": "Rule " + ruleID}
+	return map[string]interface{}{"data": map[string]interface{}{"output": map[string]interface{}{"rule": rule}}}, nil, nil
+}
+
+type echoSession struct {
+	echoBackend
+}
+
+func (session echoSession) Close() error {
+	return nil
+}
+
+// Run with -race to check that the workers and the collector
+// share no state
+func Test_RunTestPoolResults(t *testing.T) {
+	logDir := t.TempDir()
+
+	var testDirs []TestDir
+	total := 0
+	for d := 0; d < 3; d++ {
+		testDir := TestDir{TestDir: testdef.TestDir{Path: "dir" + strconv.Itoa(d)}}
+		for i := 0; i < 20; i++ {
+			ruleID := strconv.Itoa(100000 + d*100 + i)
+
+			// Mix plain tests reading a log file with
+			// sequence tests using a session
+			test := testdef.LogTest{ID: ruleID, RuleID: ruleID, RuleLevel: "3", RuleDescription: "Rule " + ruleID, Format: "syslog"}
+			if i%2 == 0 {
+				test.LogFilePath = filepath.Join(logDir, ruleID+".txt")
+				if err := os.WriteFile(test.LogFilePath, []byte("rule "+ruleID), 0o644); err != nil {
+					t.Fatalf("Failed to write log file: %v", err)
+				}
+			} else {
+				test.Sequence = []testdef.SequenceStep{{Log: "rule " + ruleID, RuleID: ruleID}}
+			}
+			testDir.Tests = append(testDir.Tests, test)
+			total++
+		}
+		testDirs = append(testDirs, testDir)
+	}
+
+	backend := echoBackend{delay: func(ruleID string) time.Duration {
+		n, _ := strconv.Atoi(ruleID)
+		return time.Duration(n%7) * time.Millisecond
+	}}
+
+	completed := map[string]string{}
+	RunTestPool(context.Background(), backend, testDirs, 8, func(test testdef.LogTest, result Result) {
+		completed[test.ID] = result.Response.Data.Output.Rule.ID
+	})
+
+	for _, testDir := range testDirs {
+		if len(testDir.Results) != len(testDir.Tests) {
+			t.Fatalf("RunTestPool() %s has %d results for %d tests", testDir.Path, len(testDir.Results), len(testDir.Tests))
+		}
+		for i, test := range testDir.Tests {
+			result := testDir.Results[i]
+			if got := result.Response.Data.Output.Rule.ID; got != test.RuleID || !result.Passed {
+				t.Errorf("RunTestPool() %s result = rule %q errors %v, want rule %s passed", test.ID, got, result.Errors, test.RuleID)
+			}
+			if completed[test.ID] != test.RuleID {
+				t.Errorf("RunTestPool() completed %s with rule %q, want %s", test.ID, completed[test.ID], test.RuleID)
+			}
+		}
+	}
+	if len(completed) != total {
+		t.Errorf("RunTestPool() completed %d tests, want %d", len(completed), total)
+	}
+}
+
+func Test_RunTestPoolCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testDirs := []TestDir{{TestDir: testdef.TestDir{Path: "tests", Tests: []testdef.LogTest{
+		{ID: "first", Sequence: []testdef.SequenceStep{{Log: "rule 5710", RuleID: "5710"}}},
+		{ID: "second", Sequence: []testdef.SequenceStep{{Log: "rule 5710", RuleID: "5710"}}},
+	}}}}
+
+	sent := 0
+	backend := echoBackend{delay: func(ruleID string) time.Duration {
+		sent++
+		return 0
+	}}
+	var completed []string
+	RunTestPool(ctx, backend, testDirs, 1, func(test testdef.LogTest, result Result) {
+		completed = append(completed, test.ID+" "+result.GetStatus())
+	})
+
+	if sent != 0 {
+		t.Errorf("RunTestPool() sent %d events after the run was cancelled, want 0", sent)
+	}
+	for i, result := range testDirs[0].Results {
+		if !result.Cancelled || result.Passed {
+			t.Errorf("RunTestPool() result %d = %+v, want cancelled", i, result)
+		}
+	}
+
+	// Every test is still reported once
+	want := []string{"first cancelled", "second cancelled"}
+	if strings.Join(completed, ",") != strings.Join(want, ",") {
+		t.Errorf("RunTestPool() completed = %v, want %v", completed, want)
+	}
+}
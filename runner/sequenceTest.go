@@ -0,0 +1,139 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// Runs every step of a sequence test in order on a dedicated
+// session. The sequence stops at the first step that fails
+// since the following steps depend on the state it left.
+func runSequenceTest(ctx context.Context, backend wazuh.LogTestBackend, logTest testdef.LogTest) (result Result) {
+	result.Passed = true
+
+	session := backend.NewSession()
+	defer func() {
+		if err := session.Close(); err != nil {
+			result.Warnings = append(result.Warnings, "Error closing logtest session: "+err.Error())
+		}
+	}()
+
+	for i, step := range logTest.Sequence {
+		event := step.Log
+		if step.LogFilePath != "" {
+			logData, err := os.ReadFile(step.LogFilePath)
+			if err != nil {
+				result.Passed = false
+				result.Errors = append(result.Errors, fmt.Sprintf("Step %d: Error opening log file: %s", i+1, err))
+				return result
+			}
+			event = logTest.FillVars(string(logData))
+		}
+
+		format := step.Format
+		if format == "" {
+			format = logTest.GetFormat()
+		}
+
+		repeat := step.GetRepeat()
+		for r := 1; r <= repeat; r++ {
+			label := "Step " + strconv.Itoa(i+1)
+			if repeat > 1 {
+				label += fmt.Sprintf(" (event %d/%d)", r, repeat)
+			}
+
+			// Every event gets its own time when rewriting to now
+			stepEvent, predecoder, timestampWarnings := testdef.ApplyTimestamp(logTest.Timestamp, event, step.Predecoder)
+			for _, w := range timestampWarnings {
+				result.Warnings = append(result.Warnings, label+": "+w)
+			}
+			step := step
+			step.Predecoder = predecoder
+
+			response, warnings, duration, err := sendTimedLogTestEvent(ctx, session, stepEvent, format, logTest.GetTimeout())
+			result.Duration += duration
+			result.Events = append(result.Events, stepEvent)
+			for _, w := range warnings {
+				result.Warnings = append(result.Warnings, label+": "+w)
+			}
+			if err != nil {
+				result.Passed = false
+				result.Errors = append(result.Errors, label+": "+err.Error())
+				return result
+			}
+			result.Response = response
+			result.Steps = append(result.Steps, response)
+
+			passed, stepErrors, stepWarnings := validateSequenceStep(step, response)
+			for _, w := range stepWarnings {
+				result.Warnings = append(result.Warnings, label+": "+w)
+			}
+			if !passed {
+				result.Passed = false
+				for _, e := range stepErrors {
+					result.Errors = append(result.Errors, label+": "+e)
+				}
+				return result
+			}
+		}
+	}
+
+	return result
+}
+
+// Compares the response to a step with the expectations
+// that are set on the step. Like validateLogTestResponse
+// it returns at the first failed check.
+func validateSequenceStep(step testdef.SequenceStep, response wazuh.Response) (bool, []string, []string) {
+	checks := []func() (bool, []string, []string){}
+
+	if step.RuleID != "" {
+		checks = append(checks, func() (bool, []string, []string) {
+			return validateRuleID(step.RuleID, response.Data.Output.Rule.ID)
+		})
+	}
+
+	if step.RuleLevel != "" {
+		checks = append(checks, func() (bool, []string, []string) {
+			expectedRuleLevel, err := strconv.Atoi(step.RuleLevel)
+			if err != nil {
+				return false, []string{"Error converting returned RuleLevel to int: " + err.Error()}, nil
+			}
+			return validateRuleLevel(expectedRuleLevel, response.Data.Output.Rule.Level)
+		})
+	}
+
+	if step.RuleDescription != "" {
+		checks = append(checks, func() (bool, []string, []string) {
+			return validateRuleDescription(step.RuleDescription, response.Data.Output.Rule.Description)
+		})
+	}
+
+	checks = append(checks,
+		func() (bool, []string, []string) {
+			return validateDecoder(step.Predecoder, response.Data.Output.Predecoder, response.Data.Output.Data, "Pre-decoder")
+		},
+		func() (bool, []string, []string) {
+			return validateDecoder(step.Decoder, response.Data.Output.Decoder, response.Data.Output.Data, "Decoder")
+		},
+		func() (bool, []string, []string) {
+			return validateData(step.Data, response.Data.Output.Data)
+		},
+		func() (bool, []string, []string) {
+			return testdef.ValidateExpectations(step.Expect, response.Raw)
+		},
+	)
+
+	for _, check := range checks {
+		if passed, errors, warnings := check(); !passed {
+			return false, errors, warnings
+		}
+	}
+
+	return true, nil, nil
+}
@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// Fires 5710 for every failed login and 5712 for every
+// 8th one on the same session, like a frequency rule.
+type fakeFrequencyBackend struct {
+	lock   sync.Mutex
+	shared int
+}
+
+type fakeFrequencySession struct {
+	count *int
+	lock  *sync.Mutex
+}
+
+func (backend *fakeFrequencyBackend) NewSession() wazuh.LogTestSession {
+	return &fakeFrequencySession{count: new(int), lock: new(sync.Mutex)}
+}
+
+func (backend *fakeFrequencyBackend) SendLogTest(ctx context.Context, event string, logFormat string) (map[string]interface{}, []string, error) {
+	return (&fakeFrequencySession{count: &backend.shared, lock: &backend.lock}).SendLogTest(ctx, event, logFormat)
+}
+
+func (session *fakeFrequencySession) SendLogTest(ctx context.Context, event string, logFormat string) (map[string]interface{}, []string, error) {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+
+	rule := map[string]interface{}{"id": "1", "level": 0, "description": "Template"}
+	if strings.Contains(event, "Invalid user") {
+		*session.count++
+		rule = map[string]interface{}{"id": "5710", "level": 5, "description": "sshd: Attempt to login using a non-existent user"}
+		if *session.count%8 == 0 {
+			rule = map[string]interface{}{"id": "5712", "level": 10, "description": "sshd: brute force trying to get access to the system."}
+		}
+	}
+	rule["firedtimes"] = float64(*session.count)
+
+	return map[string]interface{}{"data": map[string]interface{}{"output": map[string]interface{}{"rule": rule}}}, nil, nil
+}
+
+func (session *fakeFrequencySession) Close() error {
+	return nil
+}
+
+func Test_runSequenceTest(t *testing.T) {
+	login := "Mar  5 13:49:34 ip-10-0-0-10 sshd[1602]: Invalid user bob from 192.168.1.4 port 59528"
+
+	tests := []struct {
+		name       string
+		sequence   []testdef.SequenceStep
+		wantPassed bool
+		wantSteps  int
+		wantError  string
+	}{
+		{
+			name: "Valid brute force",
+			sequence: []testdef.SequenceStep{
+				{Log: login, Repeat: 7, RuleID: "5710", RuleLevel: "5"},
+				{Log: login, RuleID: "5712", RuleLevel: "10", Expect: map[string]interface{}{"rule.firedtimes": 8.0}},
+			},
+			wantPassed: true,
+			wantSteps:  8,
+		},
+		{
+			name: "Valid steps without expectations",
+			sequence: []testdef.SequenceStep{
+				{Log: "unrelated"},
+				{Log: login, Repeat: 7},
+				{Log: login, RuleID: "5712"},
+			},
+			wantPassed: true,
+			wantSteps:  9,
+		},
+
+		{
+			name: "Invalid stops at the first failed event",
+			sequence: []testdef.SequenceStep{
+				{Log: login, Repeat: 8, RuleID: "5710"},
+				{Log: login, RuleID: "5712"},
+			},
+			wantPassed: false,
+			wantSteps:  8,
+			wantError:  "Step 1 (event 8/8): Expected RuleID: 5710 Got RuleID: 5712",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			backend := &fakeFrequencyBackend{}
+
+			// Events on the shared session must not
+			// change the count of the sequence
+			for i := 0; i < 3; i++ {
+				if _, _, err := backend.SendLogTest(context.Background(), login, "syslog"); err != nil {
+					t.Fatalf("sendLogTest() error = %v", err)
+				}
+			}
+
+			lt := testdef.LogTest{Format: "syslog", Sequence: tt.sequence}
+			result := runSequenceTest(context.Background(), backend, lt)
+
+			if result.Passed != tt.wantPassed {
+				t.Errorf("runSequenceTest() passed = %v, want %v (errors: %v)", result.Passed, tt.wantPassed, result.Errors)
+			}
+			if len(result.Steps) != tt.wantSteps {
+				t.Errorf("runSequenceTest() returned %d responses, want %d", len(result.Steps), tt.wantSteps)
+			}
+			if tt.wantError != "" && (len(result.Errors) == 0 || result.Errors[0] != tt.wantError) {
+				t.Errorf("runSequenceTest() errors = %v, want %q", result.Errors, tt.wantError)
+			}
+			if got := len(lt.GetSequenceEvents()); tt.wantPassed && got != tt.wantSteps {
+				t.Errorf("getSequenceEvents() returned %d events, want %d", got, tt.wantSteps)
+			}
+		})
+	}
+}
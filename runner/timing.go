@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// Sends a single event like sendLogTestEvent and returns how
+// long the backend took. With a timeout the request is
+// cancelled once it has passed. The manager uses it instead
+// of its -o API timeout, so it can be longer or shorter.
+func sendTimedLogTestEvent(ctx context.Context, sender wazuh.LogTestSender, event string, logFormat string, timeout time.Duration) (wazuh.Response, []string, time.Duration, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	response, warnings, err := sendLogTestEvent(ctx, sender, event, logFormat)
+	duration := time.Since(start)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return wazuh.Response{}, warnings, duration, errors.New("Test timed out after " + FormatDuration(timeout) + " waiting for the logtest response")
+	}
+
+	return response, warnings, duration, err
+}
+
+// Formats durations the same way in every report
+func FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+	}
+	return fmt.Sprintf("%.2fs", d.Seconds())
+}
+
+// A test along with how long it took
+type Timing struct {
+	ID          string
+	Description string
+	Duration    time.Duration
+}
+
+// The n tests that took the longest, slowest first.
+// Skipped tests are left out.
+func SlowestTests(testDirs []TestDir, n int) []Timing {
+	var timings []Timing
+	for _, dir := range testDirs {
+		for i, test := range dir.Tests {
+			result := dir.Results[i]
+			if result.Skipped {
+				continue
+			}
+			timings = append(timings, Timing{ID: test.ID, Description: test.GetDisplayName(), Duration: result.Duration})
+		}
+	}
+
+	// Ties are sorted by ID to keep the report stable
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].Duration != timings[j].Duration {
+			return timings[i].Duration > timings[j].Duration
+		}
+		return timings[i].ID < timings[j].ID
+	})
+
+	if len(timings) > n {
+		timings = timings[:n]
+	}
+
+	return timings
+}
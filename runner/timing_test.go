@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/testdef"
+)
+
+// Answers every event after a delay unless the request is
+// cancelled first
+type slowBackend struct {
+	delay time.Duration
+}
+
+func (backend slowBackend) SendLogTest(ctx context.Context, event string, logFormat string) (map[string]interface{}, []string, error) {
+	select {
+	case <-time.After(backend.delay):
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+	return map[string]interface{}{"data": map[string]interface{}{"output": map[string]interface{}{"rule": map[string]interface{}{"id": "5710"}}}}, nil, nil
+}
+
+func Test_sendTimedLogTestEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		delay     time.Duration
+		timeout   time.Duration
+		wantError string
+	}{
+		{name: "Valid no timeout", delay: 20 * time.Millisecond},
+		{name: "Valid within timeout", delay: 20 * time.Millisecond, timeout: time.Second},
+
+		{name: "Invalid timed out", delay: 500 * time.Millisecond, timeout: 20 * time.Millisecond, wantError: "Test timed out after 20ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			response, _, duration, err := sendTimedLogTestEvent(context.Background(), slowBackend{delay: tt.delay}, "event", "syslog", tt.timeout)
+
+			if tt.wantError != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantError) {
+					t.Errorf("sendTimedLogTestEvent() error = %v, want %q", err, tt.wantError)
+				}
+				if duration >= tt.delay {
+					t.Errorf("sendTimedLogTestEvent() waited %s, want less than %s", duration, tt.delay)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("sendTimedLogTestEvent() error = %v", err)
+			}
+			if response.Data.Output.Rule.ID != "5710" {
+				t.Errorf("sendTimedLogTestEvent() rule = %q, want 5710", response.Data.Output.Rule.ID)
+			}
+			if duration < tt.delay {
+				t.Errorf("sendTimedLogTestEvent() duration = %s, want at least %s", duration, tt.delay)
+			}
+		})
+	}
+}
+
+func Test_SlowestTests(t *testing.T) {
+	testDirs := []TestDir{
+		{TestDir: testdef.TestDir{Path: "tests/ubuntu", Tests: []testdef.LogTest{{ID: "a"}, {ID: "b"}, {ID: "c", Skip: "broken"}}}, Results: []Result{
+			{Duration: 10 * time.Millisecond},
+			{Duration: 30 * time.Millisecond},
+			{Skipped: true},
+		}},
+		{TestDir: testdef.TestDir{Path: "tests", Tests: []testdef.LogTest{{ID: "e"}, {ID: "d"}}}, Results: []Result{
+			{Duration: 20 * time.Millisecond},
+			{Duration: 20 * time.Millisecond},
+		}},
+	}
+
+	var ids []string
+	for _, timing := range SlowestTests(testDirs, 3) {
+		ids = append(ids, timing.ID)
+	}
+	if strings.Join(ids, ",") != "b,d,e" {
+		t.Errorf("SlowestTests() = %v, want [b d e]", ids)
+	}
+
+	if got := SlowestTests(testDirs, 10); len(got) != 4 {
+		t.Errorf("SlowestTests() = %v, want every test that ran", got)
+	}
+}
+
+func Test_FormatDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{duration: 0, want: "0ms"},
+		{duration: 1500 * time.Microsecond, want: "1ms"},
+		{duration: 250 * time.Millisecond, want: "250ms"},
+		{duration: 1500 * time.Millisecond, want: "1.50s"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.duration); got != tt.want {
+			t.Errorf("FormatDuration(%s) = %q, want %q", tt.duration, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package runner
+
+import (
+	"strconv"
+
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// This function will compare the expected response
+// with the actual response from the Wazuh server.
+func validateLogTestResponse(logTest testdef.LogTest, response wazuh.Response) (bool, []string, []string) {
+	// Note: All the errors and warnings
+	// that we recieve from the validation
+	// functions are related to the value
+	// that is returned from the Wazuh server.
+	//
+	// At this point the LogTests have already
+	// been validated for correctness.
+	//
+	// We will return early for all errors
+	// to prevent giving back more errors
+	// than necessary.
+	var errors []string
+	var warnings []string
+	var passed bool = true
+
+	// ======( RuleID Validation )====== //
+	passed, ruleIDErrors, ruleIDWarnings := validateRuleID(logTest.GetRuleID(), response.Data.Output.Rule.ID)
+	if !passed {
+		errors = append(errors, ruleIDErrors...)
+		warnings = append(warnings, ruleIDWarnings...)
+		return passed, errors, warnings
+	}
+
+	// ======( RuleLevel Validation )====== //
+	expectedRuleLevel, err := strconv.Atoi(logTest.GetRuleLevel())
+	if err != nil {
+		errors = append(errors, "Error converting returned RuleLevel to int: "+err.Error())
+		return false, errors, warnings
+	}
+
+	passed, ruleLevelErrors, ruleLevelWarnings := validateRuleLevel(expectedRuleLevel, response.Data.Output.Rule.Level)
+	if !passed {
+		errors = append(errors, ruleLevelErrors...)
+		warnings = append(warnings, ruleLevelWarnings...)
+		return passed, errors, warnings
+	}
+
+	// ======( RuleDescription Validation )====== //
+	passed, ruleDescriptionErrors, ruleDescriptionWarnings := validateRuleDescription(logTest.GetRuleDescription(), response.Data.Output.Rule.Description)
+	if !passed {
+		errors = append(errors, ruleDescriptionErrors...)
+		warnings = append(warnings, ruleDescriptionWarnings...)
+		return passed, errors, warnings
+	}
+
+	// ======( Predecoder Validation )====== //
+	passed, predecoderErrors, predecoderWarnings := validateDecoder(logTest.GetPredecoder(), response.Data.Output.Predecoder, response.Data.Output.Data, "Pre-decoder")
+	if !passed {
+		errors = append(errors, predecoderErrors...)
+		warnings = append(warnings, predecoderWarnings...)
+		return passed, errors, warnings
+	}
+
+	// ======( Decoder Validation )====== //
+	passed, decoderErrors, decoderWarnings := validateDecoder(logTest.GetDecoder(), response.Data.Output.Decoder, response.Data.Output.Data, "Decoder")
+	if !passed {
+		errors = append(errors, decoderErrors...)
+		warnings = append(warnings, decoderWarnings...)
+		return passed, errors, warnings
+	}
+
+	// ======( Data Validation )====== //
+	passed, dataErrors, dataWarnings := validateData(logTest.GetData(), response.Data.Output.Data)
+	if !passed {
+		errors = append(errors, dataErrors...)
+		warnings = append(warnings, dataWarnings...)
+		return passed, errors, warnings
+	}
+
+	// ======( Expect Validation )====== //
+	passed, expectErrors, expectWarnings := testdef.ValidateExpectations(logTest.GetExpect(), response.Raw)
+	if !passed {
+		errors = append(errors, expectErrors...)
+		warnings = append(warnings, expectWarnings...)
+		return passed, errors, warnings
+	}
+
+	return passed, errors, warnings
+}
+
+// This function will check for messages in
+func extractLogTestMessages(result map[string]interface{}) (bool, []string, []string) {
+	// Based on what I can find from the documentation
+	// and personal experience, there appear to be
+	// only INFO and WARNING messages.
+	var warnings []string
+	var info []string
+	var haveMessages bool = false
+
+	// Check if the messages key exists
+	messages, ok := result["data"].(map[string]interface{})["messages"]
+	if !ok {
+		return false, nil, nil
+	}
+
+	// Check if the messages key is empty
+	if len(messages.([]interface{})) == 0 {
+		return false, nil, nil
+	}
+
+	// Extract the messages
+	// regexp.MustCompile()
+	// for _, message := range messages.([]interface{}) {
+
+	return haveMessages, warnings, info
+}
+
+// This function will validate the RuleID returned by the Wazuh server
+func validateRuleID(expected string, got string) (bool, []string, []string) {
+	var errors []string
+	var warnings []string
+
+	// Note: we are assuming that
+	// expected is correct and would
+	// pass an IsValidRuleID check.
+	//
+	// We check first if the recieved
+	// RuleID is invalid to provide
+	// better feedback to the user.
+	//
+	// We also return early if the RuleID
+	// is invalid to prevent giving back
+	// more errors than necessary.
+	if got == "" {
+		errors = append(errors, "RuleID is empty")
+		return false, errors, warnings
+
+	}
+
+	// Check if the returned RuleID is valid
+	valid, valErrors, valWarnigns := testdef.IsValidRuleID(got)
+	errors = append(errors, valErrors...)
+	warnings = append(warnings, valWarnigns...)
+	if !valid {
+		return false, errors, warnings
+	}
+
+	// Check if the expected RuleID matches the
+	// returned RuleID
+	if expected != got {
+		errors = append(errors, "Expected RuleID: "+expected+" Got RuleID: "+got)
+		return false, errors, warnings
+	}
+
+	return true, errors, warnings
+}
+
+// This function will validate the RuleLevel returned by the Wazuh server
+func validateRuleLevel(expected int, got int) (bool, []string, []string) {
+	var errors []string
+	var warnings []string
+
+	// Check if the RuleLevel is valid
+	valid, valErrors, valWarnings := testdef.IsValidRuleLevel(strconv.Itoa(got))
+	errors = append(errors, valErrors...)
+	warnings = append(warnings, valWarnings...)
+	if !valid {
+		return false, errors, warnings
+	}
+
+	// Check if the expected RuleLevel matches the
+	// returned RuleLevel
+	if expected != got {
+		errors = append(errors, "Expected RuleLevel: "+strconv.Itoa(expected)+" Got RuleLevel: "+strconv.Itoa(got))
+		return false, errors, warnings
+	}
+
+	return true, errors, warnings
+}
+
+// This function will validate the RuleDescription returned by the Wazuh server
+func validateRuleDescription(expected string, got string) (bool, []string, []string) {
+	var errors []string
+	var warnings []string
+
+	// =====( RuleDescription Validation )=====
+	if got == "" {
+		errors = append(errors, "RuleDescription is empty")
+		return false, errors, warnings
+	}
+
+	// Check if the RuleDescription is valid
+	valid, valErrors, valWarnings := testdef.IsValidRuleDescription(got)
+	errors = append(errors, valErrors...)
+	warnings = append(warnings, valWarnings...)
+	if !valid {
+		return false, errors, warnings
+	}
+
+	// Check if the expected RuleDescription matches the
+	// returned RuleDescription
+	if expected != got {
+		errors = append(errors, "Expected RuleDescription: "+expected+" Got RuleDescription: "+got)
+		return false, errors, warnings
+	}
+
+	return true, errors, warnings
+}
+
+// Keys in the expected map can be dotted paths (e.g.
+// `win.eventdata.targetUserName`) which are looked up
+// in the nested data dictionary.
+func validateDecoder(expected map[string]string, gotDecoder map[string]string, gotData map[string]interface{}, decoderType string) (bool, []string, []string) {
+	var errors []string
+	var warnings []string
+	var passed bool = true
+
+	// Check if the expected Decoder is empty
+	// this means that the test does not care
+	// about the Decoder output.
+	if len(expected) == 0 {
+		return true, errors, warnings
+	}
+
+	for key, val := range expected {
+		// Check if the key exists in the returned Decoder
+		// or the data dictionary
+		var foundVal interface{}
+		decoderVal, decoderOk := gotDecoder[key]
+		dataVal, dataOk := testdef.LookupJSONPath(gotData, key)
+		if decoderOk {
+			foundVal = decoderVal
+		} else if dataOk {
+			foundVal = dataVal
+		} else {
+			passed = false
+			errors = append(errors, "Expected key: "+key+" not found in returned "+decoderType)
+			continue
+		}
+
+		// Check if the value of the key matches the expected value
+		if !testdef.JSONValueMatches(val, foundVal) {
+			passed = false
+			errors = append(errors, "Expected value: "+val+" for key: "+key+" in returned "+decoderType+" Got value: "+testdef.FormatJSONValue(foundVal))
+			continue
+		}
+	}
+
+	return passed, errors, warnings
+}
+
+// Validates the expected Data values against the
+// data dictionary returned by the Wazuh server.
+// Keys can be dotted paths into nested objects.
+func validateData(expected map[string]interface{}, gotData map[string]interface{}) (bool, []string, []string) {
+	var errors []string
+	var warnings []string
+	var passed bool = true
+
+	if len(expected) == 0 {
+		return true, errors, warnings
+	}
+
+	for key, val := range expected {
+		foundVal, ok := testdef.LookupJSONPath(gotData, key)
+		if !ok {
+			passed = false
+			errors = append(errors, "Expected key: "+key+" not found in returned Data")
+			continue
+		}
+
+		if !testdef.JSONValueMatches(val, foundVal) {
+			passed = false
+			errors = append(errors, "Expected value: "+testdef.FormatJSONValue(val)+" for key: "+key+" in returned Data Got value: "+testdef.FormatJSONValue(foundVal))
+			continue
+		}
+	}
+
+	return passed, errors, warnings
+}
@@ -0,0 +1,40 @@
+package runner
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decodeTestJSON(t *testing.T, raw string) interface{} {
+	var val interface{}
+	if err := json.Unmarshal([]byte(raw), &val); err != nil {
+		t.Fatalf("Failed to decode test JSON: %v", err)
+	}
+	return val
+}
+
+func Test_validateDecoderNestedData(t *testing.T) {
+	gotData := decodeTestJSON(t, `{"win": {"eventdata": {"targetUserName": "bob"}, "system": {"eventID": 4625}}}`).(map[string]interface{})
+	gotDecoder := map[string]string{"name": "windows_eventchannel"}
+
+	passed, errors, _ := validateDecoder(map[string]string{
+		"name":                         "windows_eventchannel",
+		"win.eventdata.targetUserName": "bob",
+		"win.system.eventID":           "4625",
+	}, gotDecoder, gotData, "Decoder")
+	if !passed {
+		t.Errorf("validateDecoder() failed with errors: %v", errors)
+	}
+
+	passed, errors, _ = validateDecoder(map[string]string{
+		"win.eventdata.targetUserName": "alice",
+	}, gotDecoder, gotData, "Decoder")
+	if passed {
+		t.Errorf("validateDecoder() passed with a mismatched nested value")
+	}
+	wantErrors := []string{"Expected value: alice for key: win.eventdata.targetUserName in returned Decoder Got value: bob"}
+	if !reflect.DeepEqual(errors, wantErrors) {
+		t.Errorf("validateDecoder() errors = %v, want %v", errors, wantErrors)
+	}
+}
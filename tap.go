@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+)
+
+// Writes test results in the Test Anything Protocol
+// (version 13). Tests are numbered in the order they
+// complete.
+type tapReporter struct {
+	runner.NopReporter
+	w     io.Writer
+	count int
+}
+
+// The plan covers every test that was loaded, including the
+// skipped ones, and every test that failed to load. Tests
+// that failed to load are reported right away since they
+// will never complete.
+func (tap *tapReporter) RunStart(testDirs []runner.TestDir) {
+	total := 0
+	for _, dir := range testDirs {
+		total += len(dir.Tests)
+		for _, issue := range dir.LoadIssues {
+			if len(issue.Errors) > 0 {
+				total++
+			}
+		}
+	}
+
+	fmt.Fprintln(tap.w, "TAP version 13")
+	fmt.Fprintf(tap.w, "1..%d\n", total)
+
+	for _, dir := range testDirs {
+		for _, issue := range dir.LoadIssues {
+			if len(issue.Errors) > 0 {
+				tap.writeLine(false, "Failed to load "+issue.GetName(), "")
+				tap.writeDiagnostics(issue.Errors, issue.Warnings, "")
+			}
+		}
+	}
+}
+
+func (tap *tapReporter) TestResult(test testdef.LogTest, result runner.Result) {
+	description := test.ID + " (" + test.GetRuleLabel() + ")"
+	if test.GetTestDescription() != "" {
+		description += " " + test.GetTestDescription()
+	}
+
+	// A TODO test is expected to fail and does
+	// not fail the run either way
+	switch result.GetStatus() {
+	case runner.StatusSkipped:
+		tap.writeLine(true, description, "SKIP "+test.Skip)
+	case runner.StatusCancelled:
+		tap.writeLine(false, description, "SKIP cancelled")
+	case runner.StatusExpectedFail:
+		tap.writeLine(false, description, "TODO "+test.ExpectFail)
+	case runner.StatusUnexpectedPass:
+		tap.writeLine(true, description, "TODO "+test.ExpectFail)
+	default:
+		tap.writeLine(result.Passed, description, "")
+	}
+
+	if !result.Skipped && !result.Cancelled {
+		duration := ""
+		if result.Duration > 0 {
+			duration = runner.FormatDuration(result.Duration)
+		}
+		tap.writeDiagnostics(result.Errors, result.Warnings, duration)
+	}
+}
+
+func (tap *tapReporter) writeLine(ok bool, description string, directive string) {
+	tap.count++
+
+	line := "ok " + strconv.Itoa(tap.count)
+	if !ok {
+		line = "not " + line
+	}
+	line += " - " + escapeTAP(description)
+	if directive != "" {
+		line += " # " + escapeTAP(directive)
+	}
+
+	fmt.Fprintln(tap.w, line)
+}
+
+// A YAML block below the test line. It is only written
+// when there are errors or warnings.
+func (tap *tapReporter) writeDiagnostics(errors []string, warnings []string, duration string) {
+	if len(errors) == 0 && len(warnings) == 0 {
+		return
+	}
+
+	fmt.Fprintln(tap.w, "  ---")
+	for _, list := range []struct {
+		key   string
+		items []string
+	}{{"errors", errors}, {"warnings", warnings}} {
+		if len(list.items) == 0 {
+			continue
+		}
+		fmt.Fprintf(tap.w, "  %s:\n", list.key)
+		for _, item := range list.items {
+			fmt.Fprintf(tap.w, "    - %s\n", quoteYAML(item))
+		}
+	}
+	if duration != "" {
+		fmt.Fprintf(tap.w, "  duration: %s\n", quoteYAML(duration))
+	}
+	fmt.Fprintln(tap.w, "  ...")
+}
+
+// A # starts a directive and a line break ends the test
+// line so both are escaped
+func escapeTAP(text string) string {
+	text = strings.ReplaceAll(text, "\\", "\\\\")
+	text = strings.ReplaceAll(text, "#", "\\#")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Double quoted YAML accepts the escapes of a Go string
+// literal for the characters that end up in messages
+func quoteYAML(text string) string {
+	return strconv.Quote(text)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+)
+
+func Test_tapReporter(t *testing.T) {
+	testDirs := []runner.TestDir{{TestDir: testdef.TestDir{
+		Path: "tests/sshd",
+		Tests: []testdef.LogTest{
+			{ID: "sshd/5710.json#1", TestDescription: "SSH invalid user", RuleID: "5710"},
+			{ID: "sshd/5715.json#1", RuleID: "5715"},
+			{ID: "sshd/5716.json#1", RuleID: "5716", Skip: "Flaky on 4.7"},
+			{ID: "sshd/5717.json#1", RuleID: "5717", ExpectFail: "Issue #12"},
+			{ID: "sshd/5718.json#1", RuleID: "5718"},
+		},
+		LoadIssues: []testdef.LoadIssue{
+			{Path: "tests/sshd/5712.json", Errors: []string{"RuleID must be a number"}},
+			{Path: "tests/sshd/5713.json", Warnings: []string{"No log file found"}},
+		},
+	}}}
+	tests := testDirs[0].Tests
+
+	var buf bytes.Buffer
+	tap := &tapReporter{w: &buf}
+	tap.RunStart(testDirs)
+	tap.TestResult(tests[1], runner.Result{Errors: []string{"Expected rule ID 5715, got \"5501\""}, Duration: 12 * time.Millisecond})
+	tap.TestResult(tests[2], runner.Result{Passed: true, Skipped: true})
+	tap.TestResult(tests[3], runner.Result{ExpectedFail: true, Errors: []string{"Expected rule ID 5717, got 5501"}})
+	tap.TestResult(tests[0], runner.Result{Passed: true, Warnings: []string{"Slow"}})
+	tap.TestResult(tests[4], runner.Result{Cancelled: true})
+
+	want := `TAP version 13
+1..6
+not ok 1 - Failed to load tests/sshd/5712.json: Test \#1
+  ---
+  errors:
+    - "RuleID must be a number"
+  ...
+not ok 2 - sshd/5715.json\#1 (RuleID: 5715)
+  ---
+  errors:
+    - "Expected rule ID 5715, got \"5501\""
+  duration: "12ms"
+  ...
+ok 3 - sshd/5716.json\#1 (RuleID: 5716) # SKIP Flaky on 4.7
+not ok 4 - sshd/5717.json\#1 (RuleID: 5717) # TODO Issue \#12
+  ---
+  errors:
+    - "Expected rule ID 5717, got 5501"
+  ...
+ok 5 - sshd/5710.json\#1 (RuleID: 5710) SSH invalid user
+  ---
+  warnings:
+    - "Slow"
+  ...
+not ok 6 - sshd/5718.json\#1 (RuleID: 5718) # SKIP cancelled
+`
+	if got := buf.String(); got != want {
+		t.Errorf("tapReporter output =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func Test_escapeTAP(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "Valid plain", text: "SSH invalid user", want: "SSH invalid user"},
+		{name: "Valid hash", text: "test.json#2", want: `test.json\#2`},
+		{name: "Valid backslash", text: `C:\logs`, want: `C:\\logs`},
+		{name: "Valid line break", text: "first\nsecond", want: "first second"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := escapeTAP(tt.text); got != tt.want {
+				t.Errorf("escapeTAP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
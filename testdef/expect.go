@@ -0,0 +1,311 @@
+package testdef
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A single assertion from the Expect block of a LogTest.
+//
+// Selectors are evaluated against the `data.output` object of
+// the logtest response (e.g. `rule.mitre.id`, `decoder.parent`,
+// `full_log`). Selectors starting with `$` are evaluated against
+// the root of the response instead (e.g. `$.data.messages`).
+//
+// The expected value is either a plain JSON value which must
+// equal the returned value, or an object made up of matchers:
+//
+//	"rule.mitre.id":   {"contains": "T1110"}
+//	"full_log":        {"regex": "Invalid user \\w+"}
+//	"rule.pci_dss":    {"exists": true}
+//	"data.dstuser":    {"absent": true}
+//	"rule.firedtimes": {"equals": 1}
+type expectation struct {
+	Selector string
+	path     string
+	fromRoot bool
+
+	equals      interface{}
+	hasEquals   bool
+	contains    interface{}
+	hasContains bool
+	regex       *regexp.Regexp
+	exists      *bool
+}
+
+var expectMatchers = map[string]struct{}{
+	"equals":   {},
+	"regex":    {},
+	"contains": {},
+	"exists":   {},
+	"absent":   {},
+}
+
+// Parses the Expect block of a test into a list of
+// expectations sorted by selector. All problems with
+// the block are returned as errors.
+func parseExpectations(expect map[string]interface{}) ([]expectation, []string) {
+	errors := []string{}
+	expectations := []expectation{}
+
+	selectors := make([]string, 0, len(expect))
+	for selector := range expect {
+		selectors = append(selectors, selector)
+	}
+	sort.Strings(selectors)
+
+	for _, selector := range selectors {
+		exp, err := parseExpectation(selector, expect[selector])
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		expectations = append(expectations, exp)
+	}
+
+	return expectations, errors
+}
+
+func parseExpectation(selector string, value interface{}) (expectation, error) {
+	exp := expectation{Selector: selector}
+
+	path, fromRoot, err := parseSelector(selector)
+	if err != nil {
+		return exp, err
+	}
+	exp.path = path
+	exp.fromRoot = fromRoot
+
+	// Anything that is not an object made up only
+	// of matchers is compared for equality
+	matchers, ok := value.(map[string]interface{})
+	if !ok || !isMatcherObject(matchers) {
+		exp.equals = value
+		exp.hasEquals = true
+		return exp, nil
+	}
+
+	if val, ok := matchers["equals"]; ok {
+		exp.equals = val
+		exp.hasEquals = true
+	}
+
+	if val, ok := matchers["contains"]; ok {
+		exp.contains = val
+		exp.hasContains = true
+	}
+
+	if val, ok := matchers["regex"]; ok {
+		pattern, ok := val.(string)
+		if !ok {
+			return exp, fmt.Errorf("Expect %s: regex must be a string", selector)
+		}
+
+		exp.regex, err = regexp.Compile(pattern)
+		if err != nil {
+			return exp, fmt.Errorf("Expect %s: invalid regex: %s", selector, err)
+		}
+	}
+
+	if val, ok := matchers["exists"]; ok {
+		shouldExist, ok := val.(bool)
+		if !ok {
+			return exp, fmt.Errorf("Expect %s: exists must be true or false", selector)
+		}
+		exp.exists = &shouldExist
+	}
+
+	if val, ok := matchers["absent"]; ok {
+		shouldBeAbsent, ok := val.(bool)
+		if !ok {
+			return exp, fmt.Errorf("Expect %s: absent must be true or false", selector)
+		}
+
+		shouldExist := !shouldBeAbsent
+		if exp.exists != nil && *exp.exists != shouldExist {
+			return exp, fmt.Errorf("Expect %s: exists and absent contradict each other", selector)
+		}
+		exp.exists = &shouldExist
+	}
+
+	if exp.exists != nil && !*exp.exists && (exp.hasEquals || exp.hasContains || exp.regex != nil) {
+		return exp, fmt.Errorf("Expect %s: a value matcher cannot be combined with absent", selector)
+	}
+
+	return exp, nil
+}
+
+// Only objects where every key is a matcher name are
+// treated as matchers. Any other object is an expected
+// value for an object in the response.
+func isMatcherObject(obj map[string]interface{}) bool {
+	if len(obj) == 0 {
+		return false
+	}
+
+	for key := range obj {
+		if _, ok := expectMatchers[key]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Converts a JSONPath-like selector to the dotted path
+// format used by LookupJSONPath. Both `rule.mitre.id[0]`
+// and `rule.mitre.id.0` address the first MITRE ID.
+func parseSelector(selector string) (string, bool, error) {
+	path := strings.TrimSpace(selector)
+	fromRoot := false
+
+	if path == "" {
+		return "", false, fmt.Errorf("Expect selector is empty")
+	}
+
+	if strings.HasPrefix(path, "$") {
+		fromRoot = true
+		path = strings.TrimPrefix(path, "$")
+		path = strings.TrimPrefix(path, ".")
+	}
+
+	// Convert the bracket notation to dots
+	var converted strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] != '[' {
+			converted.WriteByte(path[i])
+			continue
+		}
+
+		end := strings.IndexByte(path[i:], ']')
+		if end < 0 {
+			return "", false, fmt.Errorf("Expect selector %s has an unclosed [", selector)
+		}
+
+		index := path[i+1 : i+end]
+		if _, err := strconv.Atoi(index); err != nil {
+			return "", false, fmt.Errorf("Expect selector %s has an invalid index: %s", selector, index)
+		}
+
+		if converted.Len() > 0 {
+			converted.WriteByte('.')
+		}
+		converted.WriteString(index)
+		i += end
+	}
+	path = converted.String()
+
+	if strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") || strings.Contains(path, "..") {
+		return "", false, fmt.Errorf("Expect selector %s is not a valid path", selector)
+	}
+
+	return path, fromRoot, nil
+}
+
+// Checks every expectation against the raw logtest response
+func ValidateExpectations(expect map[string]interface{}, raw map[string]interface{}) (bool, []string, []string) {
+	var errors []string
+	var warnings []string
+	var passed bool = true
+
+	if len(expect) == 0 {
+		return true, errors, warnings
+	}
+
+	expectations, parseErrors := parseExpectations(expect)
+	if len(parseErrors) > 0 {
+		// Tests are validated when loaded so
+		// this should never happen
+		return false, parseErrors, warnings
+	}
+
+	output, _ := LookupJSONPath(raw, "data.output")
+	for _, exp := range expectations {
+		var root interface{} = output
+		if exp.fromRoot {
+			root = raw
+		}
+
+		got, found := LookupJSONPath(root, exp.path)
+		if ok, msg := exp.check(got, found); !ok {
+			passed = false
+			errors = append(errors, "Expect "+exp.Selector+": "+msg)
+		}
+	}
+
+	return passed, errors, warnings
+}
+
+// Checks a single returned value. The message describes
+// why the value did not match.
+func (exp expectation) check(got interface{}, found bool) (bool, string) {
+	if exp.exists != nil {
+		if *exp.exists && !found {
+			return false, "expected to exist but was not found"
+		}
+		if !*exp.exists {
+			if found {
+				return false, "expected to be absent Got value: " + FormatJSONValue(got)
+			}
+			return true, ""
+		}
+	}
+
+	if !found {
+		if exp.hasEquals || exp.hasContains || exp.regex != nil {
+			return false, "not found in returned output"
+		}
+		return true, ""
+	}
+
+	if exp.hasEquals && !JSONValueMatches(exp.equals, got) {
+		return false, "expected value: " + FormatJSONValue(exp.equals) + " Got value: " + FormatJSONValue(got)
+	}
+
+	if exp.hasContains && !valueContains(got, exp.contains) {
+		return false, "expected to contain: " + FormatJSONValue(exp.contains) + " Got value: " + FormatJSONValue(got)
+	}
+
+	if exp.regex != nil && !valueMatchesRegex(got, exp.regex) {
+		return false, "expected to match regex: " + exp.regex.String() + " Got value: " + FormatJSONValue(got)
+	}
+
+	return true, ""
+}
+
+// Strings contain substrings, arrays contain a matching
+// element and objects contain a matching subset.
+func valueContains(got interface{}, expected interface{}) bool {
+	switch g := got.(type) {
+	case string:
+		return strings.Contains(g, FormatJSONValue(expected))
+	case []interface{}:
+		for _, elem := range g {
+			if JSONValueMatches(expected, elem) {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		return JSONValueMatches(expected, g)
+	}
+
+	return JSONValueMatches(expected, got)
+}
+
+// Arrays match if any of their elements match
+func valueMatchesRegex(got interface{}, regex *regexp.Regexp) bool {
+	if arr, ok := got.([]interface{}); ok {
+		for _, elem := range arr {
+			if regex.MatchString(FormatJSONValue(elem)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return regex.MatchString(FormatJSONValue(got))
+}
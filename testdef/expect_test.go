@@ -0,0 +1,70 @@
+package testdef
+
+import (
+	"reflect"
+	"testing"
+)
+
+const expectTestResponse = `{
+	"data": {
+		"messages": ["INFO: (7202): Session initialized with token 'abc'"],
+		"output": {
+			"full_log": "Mar  5 13:49:34 ip-10-0-0-10 sshd[1602]: Invalid user non-existent from 10.0.0.4 port 59528",
+			"location": "WazuhTestRunner",
+			"decoder": {"parent": "sshd", "name": "sshd"},
+			"rule": {
+				"id": "5710",
+				"level": 5,
+				"firedtimes": 1,
+				"mail": false,
+				"mitre": {"id": ["T1110.001", "T1021.004"], "tactic": ["Credential Access"]},
+				"pci_dss": ["10.2.4", "10.2.5"]
+			}
+		}
+	}
+}`
+
+func Test_ValidateExpectations(t *testing.T) {
+	raw := decodeTestJSON(t, expectTestResponse).(map[string]interface{})
+
+	tests := []struct {
+		name   string
+		expect string
+		want   bool
+		want1  []string
+	}{
+		// Passing expectations
+		{name: "Valid plain equality", expect: `{"decoder.parent": "sshd", "rule.level": 5}`, want: true},
+		{name: "Valid equals matcher", expect: `{"rule.firedtimes": {"equals": "1"}}`, want: true},
+		{name: "Valid contains in array", expect: `{"rule.mitre.id": {"contains": "T1110.001"}}`, want: true},
+		{name: "Valid contains in string", expect: `{"full_log": {"contains": "Invalid user"}}`, want: true},
+		{name: "Valid regex", expect: `{"full_log": {"regex": "from \\d+\\.\\d+\\.\\d+\\.\\d+"}}`, want: true},
+		{name: "Valid regex on array", expect: `{"rule.pci_dss": {"regex": "^10\\.2\\.5$"}}`, want: true},
+		{name: "Valid exists", expect: `{"rule.mitre.tactic": {"exists": true}}`, want: true},
+		{name: "Valid absent", expect: `{"rule.gdpr": {"absent": true}}`, want: true},
+		{name: "Valid bracket index", expect: `{"rule.mitre.id[1]": "T1021.004"}`, want: true},
+		{name: "Valid root selector", expect: `{"$.data.messages[0]": {"contains": "Session initialized"}}`, want: true},
+		{name: "Valid combined matchers", expect: `{"rule.mitre.id": {"exists": true, "contains": "T1021.004"}}`, want: true},
+
+		// Failing expectations
+		{name: "Invalid plain equality", expect: `{"rule.level": 7}`, want: false, want1: []string{"Expect rule.level: expected value: 7 Got value: 5"}},
+		{name: "Invalid contains", expect: `{"rule.mitre.id": {"contains": "T1078"}}`, want: false, want1: []string{`Expect rule.mitre.id: expected to contain: T1078 Got value: ["T1110.001","T1021.004"]`}},
+		{name: "Invalid exists", expect: `{"rule.gdpr": {"exists": true}}`, want: false, want1: []string{"Expect rule.gdpr: expected to exist but was not found"}},
+		{name: "Invalid absent", expect: `{"rule.mail": {"absent": true}}`, want: false, want1: []string{"Expect rule.mail: expected to be absent Got value: false"}},
+		{name: "Invalid missing value", expect: `{"data.srcip": "10.0.0.4"}`, want: false, want1: []string{"Expect data.srcip: not found in returned output"}},
+		{name: "Invalid regex", expect: `{"location": {"regex": "^agent"}}`, want: false, want1: []string{"Expect location: expected to match regex: ^agent Got value: WazuhTestRunner"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			expect := decodeTestJSON(t, tt.expect).(map[string]interface{})
+			got, got1, _ := ValidateExpectations(expect, raw)
+			if got != tt.want {
+				t.Errorf("ValidateExpectations() got = %v, want %v (errors: %v)", got, tt.want, got1)
+			}
+			if !tt.want && !reflect.DeepEqual(got1, tt.want1) {
+				t.Errorf("ValidateExpectations() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package testdef
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Looks up a dotted path such as `win.eventdata.targetUserName`
+// in a decoded JSON value. Keys that themselves contain dots
+// are supported by always trying the longest matching key
+// first. Array elements are addressed with their index
+// (e.g. `rule.mitre.id.0`).
+func LookupJSONPath(node interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return node, true
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		// Exact key match (also covers keys with dots)
+		if val, ok := n[path]; ok {
+			return val, true
+		}
+
+		// Try splitting at every dot starting with the
+		// longest possible key
+		for i := len(path) - 1; i > 0; i-- {
+			if path[i] != '.' {
+				continue
+			}
+
+			child, ok := n[path[:i]]
+			if !ok {
+				continue
+			}
+
+			if val, ok := LookupJSONPath(child, path[i+1:]); ok {
+				return val, true
+			}
+		}
+	case []interface{}:
+		head, rest, _ := strings.Cut(path, ".")
+		index, err := strconv.Atoi(head)
+		if err != nil || index < 0 || index >= len(n) {
+			return nil, false
+		}
+
+		return LookupJSONPath(n[index], rest)
+	}
+
+	return nil, false
+}
+
+// Compares an expected value from a test definition with a value
+// returned by the Wazuh server. Wazuh is not consistent about
+// returning numbers and booleans as strings, so scalars are
+// compared by value rather than by type:
+//
+//   - "8" matches 8 and 8 matches "8"
+//   - "true" matches true and true matches "true"
+//   - arrays must have the same length and matching elements
+//   - objects only need to match the expected keys
+//
+// A string expectation can also hold a JSON array or object
+// (e.g. `["a", "b"]`) to be compared against a returned
+// array or object.
+func JSONValueMatches(expected interface{}, got interface{}) bool {
+	switch exp := expected.(type) {
+	case nil:
+		return got == nil
+	case string:
+		return stringMatchesJSONValue(exp, got)
+	case float64:
+		switch g := got.(type) {
+		case float64:
+			return exp == g
+		case string:
+			gotNum, err := strconv.ParseFloat(g, 64)
+			return err == nil && exp == gotNum
+		}
+	case bool:
+		switch g := got.(type) {
+		case bool:
+			return exp == g
+		case string:
+			gotBool, err := strconv.ParseBool(g)
+			return err == nil && exp == gotBool
+		}
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(exp) != len(g) {
+			return false
+		}
+
+		for i := range exp {
+			if !JSONValueMatches(exp[i], g[i]) {
+				return false
+			}
+		}
+
+		return true
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		for key, val := range exp {
+			gotVal, exists := g[key]
+			if !exists || !JSONValueMatches(val, gotVal) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func stringMatchesJSONValue(expected string, got interface{}) bool {
+	switch g := got.(type) {
+	case string:
+		return expected == g
+	case float64:
+		expectedNum, err := strconv.ParseFloat(expected, 64)
+		return err == nil && expectedNum == g
+	case bool:
+		expectedBool, err := strconv.ParseBool(expected)
+		return err == nil && expectedBool == g
+	case nil:
+		return expected == "null"
+	case []interface{}, map[string]interface{}:
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(expected), &parsed); err != nil {
+			return false
+		}
+
+		// Avoid infinite recursion on strings that
+		// decode to another string
+		if _, isString := parsed.(string); isString {
+			return false
+		}
+
+		return JSONValueMatches(parsed, got)
+	}
+
+	return false
+}
+
+// Formats a decoded JSON value for error messages. Strings are
+// printed as is and whole numbers without a decimal point.
+func FormatJSONValue(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	out, err := json.Marshal(val)
+	if err != nil {
+		return "<unprintable value>"
+	}
+
+	return string(out)
+}
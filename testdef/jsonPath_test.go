@@ -0,0 +1,98 @@
+package testdef
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decodeTestJSON(t *testing.T, raw string) interface{} {
+	var val interface{}
+	if err := json.Unmarshal([]byte(raw), &val); err != nil {
+		t.Fatalf("Failed to decode test JSON: %v", err)
+	}
+	return val
+}
+
+func Test_LookupJSONPath(t *testing.T) {
+	data := decodeTestJSON(t, `{
+		"srcip": "10.0.0.4",
+		"win": {
+			"eventdata": {"targetUserName": "bob", "logonType": "3"},
+			"system": {"eventID": 4625}
+		},
+		"dotted.key": "literal",
+		"aws": {"resources": [{"name": "first"}, {"name": "second"}]}
+	}`)
+
+	tests := []struct {
+		name  string
+		path  string
+		want  interface{}
+		want1 bool
+	}{
+		{name: "Valid top level key", path: "srcip", want: "10.0.0.4", want1: true},
+		{name: "Valid nested key", path: "win.eventdata.targetUserName", want: "bob", want1: true},
+		{name: "Valid nested number", path: "win.system.eventID", want: float64(4625), want1: true},
+		{name: "Valid key containing a dot", path: "dotted.key", want: "literal", want1: true},
+		{name: "Valid array index", path: "aws.resources.1.name", want: "second", want1: true},
+		{name: "Valid nested object", path: "win.system", want: map[string]interface{}{"eventID": float64(4625)}, want1: true},
+
+		{name: "Invalid missing key", path: "dstip", want: nil, want1: false},
+		{name: "Invalid missing nested key", path: "win.eventdata.subjectUserName", want: nil, want1: false},
+		{name: "Invalid path through a string", path: "srcip.value", want: nil, want1: false},
+		{name: "Invalid array index out of range", path: "aws.resources.5.name", want: nil, want1: false},
+		{name: "Invalid non-numeric array index", path: "aws.resources.first", want: nil, want1: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, got1 := LookupJSONPath(data, tt.path)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LookupJSONPath() got = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("LookupJSONPath() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func Test_JSONValueMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		got      string
+		want     bool
+	}{
+		// Matching values
+		{name: "Valid equal strings", expected: `"sshd"`, got: `"sshd"`, want: true},
+		{name: "Valid string against number", expected: `"4625"`, got: `4625`, want: true},
+		{name: "Valid number against string", expected: `59528`, got: `"59528"`, want: true},
+		{name: "Valid string against bool", expected: `"true"`, got: `true`, want: true},
+		{name: "Valid bool against string", expected: `false`, got: `"false"`, want: true},
+		{name: "Valid equal arrays", expected: `["a", 1]`, got: `["a", "1"]`, want: true},
+		{name: "Valid JSON string against array", expected: `"[\"T1110\"]"`, got: `["T1110"]`, want: true},
+		{name: "Valid object subset", expected: `{"id": "5710"}`, got: `{"id": "5710", "level": 5}`, want: true},
+		{name: "Valid null", expected: `null`, got: `null`, want: true},
+
+		// Mismatching values
+		{name: "Invalid different strings", expected: `"sshd"`, got: `"kernel"`, want: false},
+		{name: "Invalid string against different number", expected: `"4624"`, got: `4625`, want: false},
+		{name: "Invalid non-numeric string against number", expected: `"abc"`, got: `4625`, want: false},
+		{name: "Invalid arrays with different length", expected: `["a"]`, got: `["a", "b"]`, want: false},
+		{name: "Invalid array against string", expected: `["a"]`, got: `"a"`, want: false},
+		{name: "Invalid object with missing key", expected: `{"mail": false}`, got: `{"id": "5710"}`, want: false},
+		{name: "Invalid string against null", expected: `"value"`, got: `null`, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			expected := decodeTestJSON(t, tt.expected)
+			got := decodeTestJSON(t, tt.got)
+			if matches := JSONValueMatches(expected, got); matches != tt.want {
+				t.Errorf("JSONValueMatches() got = %v, want %v", matches, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,389 @@
+package testdef
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A single directory of the test tree along with
+// the tests that were successfully loaded from it.
+type TestDir struct {
+	Path         string
+	Tests        []LogTest
+	InvalidTests int
+	NumLogFiles  int
+
+	// Errors and warnings found while loading the tests
+	LoadIssues []LoadIssue
+}
+
+// Receives the errors and warnings found while the test
+// tree is loaded
+type LoadReporter interface {
+	// A test failed to load or loaded with warnings
+	LoadIssue(issue LoadIssue)
+
+	// A warning about the loaded tests as a whole
+	Warning(message string)
+}
+
+// The errors and warnings of a single test found when
+// it was loaded. Tests with errors were not run.
+type LoadIssue struct {
+	Path  string
+	Index int
+
+	// Only set when the definition could be turned
+	// into a test
+	RuleID      string
+	Description string
+	CaseName    string
+
+	Errors   []string
+	Warnings []string
+}
+
+func newLoadIssue(path string, index int, logTest *LogTest, loadErrors []string, loadWarnings []string) LoadIssue {
+	issue := LoadIssue{Path: path, Index: index, Errors: loadErrors, Warnings: loadWarnings}
+	if logTest != nil {
+		issue.RuleID = logTest.GetRuleID()
+		issue.Description = logTest.GetTestDescription()
+		issue.CaseName = logTest.caseName
+	}
+	return issue
+}
+
+// Names the test by its definition so it can be found
+func (issue LoadIssue) GetName() string {
+	name := issue.Path + ": Test #" + strconv.Itoa(issue.Index+1)
+	if issue.RuleID != "" {
+		name += " (RuleID: " + issue.RuleID + ")"
+		if issue.Description != "" {
+			name += " " + issue.Description
+		}
+	} else if issue.CaseName != "" {
+		name += " [" + issue.CaseName + "]"
+	}
+	return name
+}
+
+// Load the test tree under rootDir and give every test its
+// ID. When filter is set only the tests whose ID matches it
+// are returned.
+func CollectTestDirs(rootDir string, filter *regexp.Regexp, reporter LoadReporter) ([]TestDir, error) {
+	testDirs, err := loadTestDirs(rootDir, reporter)
+	if err != nil {
+		return nil, err
+	}
+
+	assignTestIDs(rootDir, testDirs, reporter)
+
+	if filter != nil {
+		for i := range testDirs {
+			var matched []LogTest
+			for _, test := range testDirs[i].Tests {
+				if filter.MatchString(test.ID) {
+					matched = append(matched, test)
+				}
+			}
+			testDirs[i].Tests = matched
+		}
+	}
+
+	if numFocused := focusTestDirs(testDirs); numFocused > 0 {
+		reporter.Warning("Focus mode is active, only running the " + strconv.Itoa(numFocused) + " tests marked Only")
+	}
+
+	return testDirs, nil
+}
+
+// Recursively walk the test tree and load every test
+// definition. Subdirectories are returned before their
+// parent to keep the bottom up ordering of the runner.
+func loadTestDirs(dirPath string, reporter LoadReporter) ([]TestDir, error) {
+	var testDirs []TestDir
+
+	// List our current directory
+	files, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sort the files into test definitions, subdirectories, and other files
+	testDefs, subdirectories, otherFiles, err := sortDirContent(files)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, subdirectory := range subdirectories {
+		path := filepath.Join(dirPath, subdirectory.Name())
+		subTestDirs, err := loadTestDirs(path, reporter)
+		if err != nil {
+			return nil, err
+		}
+		testDirs = append(testDirs, subTestDirs...)
+	}
+
+	// Load all test definitions
+	currDir := TestDir{Path: dirPath, Tests: []LogTest{}, NumLogFiles: len(otherFiles)}
+	for _, testDef := range testDefs {
+		path := filepath.Join(dirPath, testDef.Name())
+		tests, currInvalidTests, issues, err := loadTestDef(path, reporter)
+
+		// This error will only occur
+		// if the test definition file (.json)
+		// has an error.
+		if err != nil {
+			return nil, fmt.Errorf("error loading test definition %s: %s", path, err)
+		}
+		currDir.Tests = append(currDir.Tests, tests...)
+		currDir.InvalidTests += currInvalidTests
+		currDir.LoadIssues = append(currDir.LoadIssues, issues...)
+	}
+
+	testDirs = append(testDirs, currDir)
+
+	return testDirs, nil
+}
+
+// When any test is marked Only, the other tests are left
+// out so work can focus on a few tests
+func focusTestDirs(testDirs []TestDir) int {
+	numFocused := 0
+	for _, dir := range testDirs {
+		for _, test := range dir.Tests {
+			if test.Only {
+				numFocused++
+			}
+		}
+	}
+	if numFocused == 0 {
+		return 0
+	}
+
+	for i := range testDirs {
+		var focused []LogTest
+		for _, test := range testDirs[i].Tests {
+			if test.Only {
+				focused = append(focused, test)
+			}
+		}
+		testDirs[i].Tests = focused
+	}
+
+	return numFocused
+}
+
+// Tests without an ID in their definition are identified by
+// the path of the definition relative to rootDir and their
+// position in it, e.g. "ubuntu/test_ssh.json#2". The cases
+// of a parameterized test add their name without
+// whitespace, e.g. "#2/IPv4,user=bob". Tests that share
+// an ID fail to load since their results could not be told
+// apart.
+func assignTestIDs(rootDir string, testDirs []TestDir, reporter LoadReporter) {
+	counts := map[string]int{}
+	for i := range testDirs {
+		for j := range testDirs[i].Tests {
+			test := &testDirs[i].Tests[j]
+			if test.ID == "" {
+				path := test.DefPath
+				if rel, err := filepath.Rel(rootDir, path); err == nil {
+					path = rel
+				}
+				test.ID = filepath.ToSlash(path) + "#" + strconv.Itoa(test.DefIndex+1)
+			}
+			if test.caseName != "" {
+				test.ID += "/" + getCaseID(test.caseName)
+			}
+			counts[test.ID]++
+		}
+	}
+
+	for i := range testDirs {
+		var unique []LogTest
+		for _, test := range testDirs[i].Tests {
+			if counts[test.ID] == 1 {
+				unique = append(unique, test)
+				continue
+			}
+
+			loadError := "ID " + test.ID + " is used by " + strconv.Itoa(counts[test.ID]) + " tests"
+			issue := newLoadIssue(test.DefPath, test.DefIndex, &test, []string{loadError}, nil)
+			reporter.LoadIssue(issue)
+			testDirs[i].LoadIssues = append(testDirs[i].LoadIssues, issue)
+			testDirs[i].InvalidTests++
+		}
+		testDirs[i].Tests = unique
+	}
+}
+
+// IDs cannot hold whitespace so it is dropped after the
+// commas between variables and replaced elsewhere
+func getCaseID(caseName string) string {
+	caseName = strings.ReplaceAll(caseName, ", ", ",")
+	return strings.Join(strings.Fields(caseName), "_")
+}
+
+func loadTestDef(path string, reporter LoadReporter) ([]LogTest, int, []LoadIssue, error) {
+	var invalidTestCount int = 0
+	var issues []LoadIssue
+
+	// Check file extension is .json
+	if filepath.Ext(path) != ".json" {
+		return nil, -1, nil, errors.New("file is not a JSON file")
+	}
+
+	// Check if path exists
+	exists, err := FileExists(path)
+	if err != nil {
+		return nil, -1, nil, err
+	}
+	if !exists {
+		return nil, 1, nil, errors.New("file does not exist")
+	}
+
+	// Open the file
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, -1, nil, err
+	}
+	defer file.Close()
+
+	// Parse JSON list of LogTest objects
+	var testGroup TestGroup
+	decoder := json.NewDecoder(file)
+	err = decoder.Decode(&testGroup)
+	if err != nil {
+		return nil, -1, nil, err
+	}
+
+	var logTests []LogTest
+	for i, definition := range testGroup.Tests {
+		// Tests with Cases or a Matrix load as one test per case
+		if definition.Timestamp == "" {
+			definition.Timestamp = testGroup.Timestamp
+		}
+
+		cases, caseErrors, caseWarnings := expandTestCases(definition)
+		if len(caseErrors) > 0 || len(caseWarnings) > 0 {
+			issue := newLoadIssue(path, i, nil, caseErrors, caseWarnings)
+			reporter.LoadIssue(issue)
+			issues = append(issues, issue)
+		}
+		if len(caseErrors) > 0 {
+			invalidTestCount++
+			continue
+		}
+
+		for _, raw := range cases {
+			var logTest *LogTest
+			var valid bool
+			var loadErrors, loadWarnings []string
+
+			if raw.IsSequence() {
+				logTest, valid, loadErrors, loadWarnings = NewSequenceTest(raw, filepath.Dir(path))
+			} else {
+				logPath := filepath.Join(filepath.Dir(path), raw.LogFilePath)
+				logTest, valid, loadErrors, loadWarnings = NewLogTest(raw.Version, raw.RuleID, raw.RuleLevel, raw.RuleDescription, logPath, raw.Format, raw.Decoder, raw.Predecoder, raw.TestDescription)
+
+				optValid, optErrors, optWarnings := logTest.setOptionalFields(raw)
+				loadErrors = append(loadErrors, optErrors...)
+				loadWarnings = append(loadWarnings, optWarnings...)
+				valid = valid && optValid
+			}
+			logTest.DefPath = path
+			logTest.DefIndex = i
+			logTest.caseName = raw.caseName
+			logTest.vars = raw.vars
+
+			// The logs can only be checked once the
+			// format and the variables are known
+			contentValid, contentErrors, contentWarnings := logTest.validateLogContent()
+			loadErrors = append(loadErrors, contentErrors...)
+			loadWarnings = append(loadWarnings, contentWarnings...)
+			valid = valid && contentValid
+
+			if len(loadErrors) > 0 || len(loadWarnings) > 0 {
+				issue := newLoadIssue(path, i, logTest, loadErrors, loadWarnings)
+				reporter.LoadIssue(issue)
+				issues = append(issues, issue)
+			}
+
+			// Do not append invalid tests
+			if valid {
+				logTests = append(logTests, *logTest)
+			} else {
+				invalidTestCount++
+			}
+		}
+	}
+
+	return logTests, invalidTestCount, issues, nil
+}
+
+// Load all test definitions from the current directory
+func sortDirContent(files []os.DirEntry) ([]os.DirEntry, []os.DirEntry, []os.DirEntry, error) {
+	var testDefs []os.DirEntry
+	var subdirectories []os.DirEntry
+	var otherFiles []os.DirEntry
+
+	// Compile the regex pattern
+	pattern := regexp.MustCompile(`^test_.*\.json$`)
+
+	for _, file := range files {
+		if file.IsDir() {
+			subdirectories = append(subdirectories, file)
+			continue
+		}
+
+		if pattern.MatchString(file.Name()) {
+			testDefs = append(testDefs, file)
+			continue
+		}
+
+		// If the file is not a directory or a test definition, then it is
+		// some other file; Likely a log file
+		otherFiles = append(otherFiles, file)
+	}
+
+	return testDefs, subdirectories, otherFiles, nil
+}
+
+func FileExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func IsDir(path string) (bool, error) {
+
+	// First check if path does not exist
+	exists, err := FileExists(path)
+	if err != nil {
+		return false, err
+	}
+
+	if !exists {
+		return false, nil
+	}
+
+	// Check if path is a directory
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	return fileInfo.IsDir(), nil
+}
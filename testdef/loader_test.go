@@ -0,0 +1,140 @@
+package testdef
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// Ignores everything found while loading
+type discardReporter struct{}
+
+func (discardReporter) LoadIssue(issue LoadIssue) {}
+func (discardReporter) Warning(message string)    {}
+
+func Test_assignTestIDs(t *testing.T) {
+	root := "tests"
+	testDirs := []TestDir{
+		{Path: "tests/ubuntu", Tests: []LogTest{
+			{DefPath: "tests/ubuntu/test_ssh.json", DefIndex: 0},
+			{DefPath: "tests/ubuntu/test_ssh.json", DefIndex: 1, caseName: "user=bob"},
+			{DefPath: "tests/ubuntu/test_ssh.json", DefIndex: 2, ID: "ssh-brute-force"},
+			{DefPath: "tests/ubuntu/test_ssh.json", DefIndex: 3, ID: "shared"},
+		}},
+		{Path: "tests", Tests: []LogTest{
+			{DefPath: "tests/test_agent.json", DefIndex: 0, ID: "shared"},
+			{DefPath: "tests/test_agent.json", DefIndex: 1, ID: "cases", caseName: "IPv4"},
+			{DefPath: "tests/test_agent.json", DefIndex: 1, ID: "cases", caseName: "Windows agent, ip=10.0.0.1"},
+		}},
+	}
+
+	assignTestIDs(root, testDirs, discardReporter{})
+
+	var ids []string
+	for _, dir := range testDirs {
+		for _, test := range dir.Tests {
+			ids = append(ids, test.ID)
+		}
+	}
+	want := []string{"ubuntu/test_ssh.json#1", "ubuntu/test_ssh.json#2/user=bob", "ssh-brute-force", "cases/IPv4", "cases/Windows_agent,ip=10.0.0.1"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("assignTestIDs() IDs = %v, want %v", ids, want)
+	}
+
+	// Both tests sharing an ID fail to load
+	if testDirs[0].InvalidTests != 1 || testDirs[1].InvalidTests != 1 {
+		t.Errorf("assignTestIDs() invalid tests = %d, %d, want 1, 1", testDirs[0].InvalidTests, testDirs[1].InvalidTests)
+	}
+}
+
+func Test_CollectTestDirs(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "5710.txt"), []byte("Mar  5 13:49:34 host sshd[1602]: Invalid user bob\n"), 0600); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+	definition := `{"Tests": [
+		{"Version": "0.1", "RuleID": "5710", "RuleLevel": "5", "Format": "syslog", "LogFilePath": "5710.txt"},
+		{"Version": "0.1", "RuleID": "5710", "RuleLevel": "5", "Format": "syslog", "LogFilePath": "5710.txt", "UUID": "legacy-id"}
+	]}`
+	if err := os.WriteFile(filepath.Join(root, "test_ssh.json"), []byte(definition), 0600); err != nil {
+		t.Fatalf("Failed to write test definition: %v", err)
+	}
+
+	// IDs are the same on every load
+	for i := 0; i < 2; i++ {
+		testDirs, err := CollectTestDirs(root, nil, discardReporter{})
+		if err != nil {
+			t.Fatalf("CollectTestDirs() error = %v", err)
+		}
+		if len(testDirs) != 1 || len(testDirs[0].Tests) != 2 {
+			t.Fatalf("CollectTestDirs() = %+v, want 2 tests", testDirs)
+		}
+		if testDirs[0].Tests[0].ID != "test_ssh.json#1" || testDirs[0].Tests[1].ID != "legacy-id" {
+			t.Errorf("CollectTestDirs() IDs = %s, %s", testDirs[0].Tests[0].ID, testDirs[0].Tests[1].ID)
+		}
+	}
+
+	testDirs, err := CollectTestDirs(root, regexp.MustCompile(`#1$`), discardReporter{})
+	if err != nil {
+		t.Fatalf("CollectTestDirs() error = %v", err)
+	}
+	if len(testDirs[0].Tests) != 1 || testDirs[0].Tests[0].ID != "test_ssh.json#1" {
+		t.Errorf("CollectTestDirs() filtered = %+v, want only test_ssh.json#1", testDirs[0].Tests)
+	}
+}
+
+// A single case of a parameterized test can be run with -run
+func Test_CollectTestDirsCase(t *testing.T) {
+	root := t.TempDir()
+	definition := `{"Tests": [
+		{"Version": "0.1", "RuleID": "5710", "RuleLevel": "5", "Format": "syslog", "Log": "Mar  5 13:49:34 host sshd[1602]: Invalid user ${user}",
+		 "Cases": [{"Name": "Unknown user", "Vars": {"user": "bob"}}, {"Vars": {"user": "alice"}}]}
+	]}`
+	if err := os.WriteFile(filepath.Join(root, "test_ssh.json"), []byte(definition), 0600); err != nil {
+		t.Fatalf("Failed to write test definition: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter string
+		want   string
+	}{
+		{name: "Valid named case", filter: `test_ssh.json#1/Unknown_user$`, want: "test_ssh.json#1/Unknown_user"},
+		{name: "Valid variables case", filter: `#1/user=alice$`, want: "test_ssh.json#1/user=alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			testDirs, err := CollectTestDirs(root, regexp.MustCompile(tt.filter), discardReporter{})
+			if err != nil {
+				t.Fatalf("CollectTestDirs() error = %v", err)
+			}
+			if len(testDirs) != 1 || len(testDirs[0].Tests) != 1 || testDirs[0].Tests[0].ID != tt.want {
+				t.Errorf("CollectTestDirs() filtered = %+v, want only %s", testDirs, tt.want)
+			}
+		})
+	}
+}
+
+func Test_focusTestDirs(t *testing.T) {
+	testDirs := []TestDir{
+		{Path: "tests/ubuntu", Tests: []LogTest{{ID: "a"}, {ID: "b", Only: true}}},
+		{Path: "tests", Tests: []LogTest{{ID: "c"}, {ID: "d", Only: true}}},
+	}
+
+	if got := focusTestDirs(testDirs); got != 2 {
+		t.Errorf("focusTestDirs() = %d, want 2", got)
+	}
+	if len(testDirs[0].Tests) != 1 || testDirs[0].Tests[0].ID != "b" || len(testDirs[1].Tests) != 1 || testDirs[1].Tests[0].ID != "d" {
+		t.Errorf("focusTestDirs() left %+v", testDirs)
+	}
+
+	// Nothing is left out without focused tests
+	unfocused := []TestDir{{Path: "tests", Tests: []LogTest{{ID: "a"}, {ID: "b"}}}}
+	if got := focusTestDirs(unfocused); got != 0 || len(unfocused[0].Tests) != 2 {
+		t.Errorf("focusTestDirs() = %d, %+v, want 0 and every test", got, unfocused)
+	}
+}
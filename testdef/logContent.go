@@ -0,0 +1,143 @@
+package testdef
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Largest event the manager accepts. Longer events are
+// truncated by analysisd (OS_MAXSTR).
+const maxEventSize = 65535
+
+// Optional RFC5424 priority and version before the timestamp
+var syslogPriorityPattern = regexp.MustCompile(`^<[0-9]{1,3}>([0-9] )?`)
+
+// A hostname or IP after the timestamp of a syslog header
+var syslogHostPattern = regexp.MustCompile(`^ \S+ `)
+
+// Returns N for the multi-line:N format
+func getMultiLineCount(format string) (int, bool) {
+	value, found := strings.CutPrefix(format, "multi-line:")
+	if !found {
+		return 0, false
+	}
+
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 1 {
+		return 0, false
+	}
+
+	return count, true
+}
+
+// Checks that the log can be sent as the given format. The
+// trailing newline of a log file is not part of the event.
+func IsValidLogContent(content string, format string) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	event := strings.TrimRight(content, "\r\n")
+
+	if len(event) > maxEventSize {
+		errors = append(errors, fmt.Sprintf("Log is %d bytes, the maximum event size is %d bytes", len(event), maxEventSize))
+	}
+
+	// Lines
+	numLines := strings.Count(event, "\n") + 1
+	if count, ok := getMultiLineCount(format); ok {
+		if numLines != count {
+			errors = append(errors, fmt.Sprintf("Log has %d lines but the format is %s", numLines, format))
+		}
+	} else if hasOneLine, _ := fileHasOneLine(strings.NewReader(event + "\n")); !hasOneLine && format != "multi-line" {
+		errors = append(errors, "Log should only have one line, use the multi-line:N format for logs that span lines")
+	}
+
+	// Contents
+	switch format {
+	case "json":
+		var value interface{}
+		if err := json.Unmarshal([]byte(event), &value); err != nil {
+			errors = append(errors, "Log is not valid JSON: "+err.Error())
+		}
+	case "eventchannel":
+		var value map[string]interface{}
+		if err := json.Unmarshal([]byte(event), &value); err != nil {
+			errors = append(errors, "Log is not a valid eventchannel JSON object: "+err.Error())
+		} else if _, ok := value["win"].(map[string]interface{}); !ok {
+			warnings = append(warnings, "Log has no win object, eventchannel events look like {\"win\": {\"system\": ...}}")
+		}
+	case "syslog":
+		if !hasSyslogHeader(event) {
+			warnings = append(warnings, "Log has no syslog header, e.g. \"Mar  5 13:49:34 hostname program: \"")
+		}
+	}
+
+	return len(errors) == 0, errors, warnings
+}
+
+// A syslog header is a timestamp followed by the hostname
+func hasSyslogHeader(event string) bool {
+	event = syslogPriorityPattern.ReplaceAllString(event, "")
+
+	for _, format := range timestampFormats {
+		if format.name != "syslog" && format.name != "ISO8601" {
+			continue
+		}
+		if timestamp := format.pattern.FindString(event); timestamp != "" {
+			return syslogHostPattern.MatchString(event[len(timestamp):])
+		}
+	}
+
+	return false
+}
+
+// Checks the contents of every log of the test against its
+// format. Placeholders are filled in first. Log files that
+// cannot be read were already reported when the test was
+// loaded so they are skipped.
+func (lt *LogTest) validateLogContent() (bool, []string, []string) {
+	validTest := true
+	errors := []string{}
+	warnings := []string{}
+
+	check := func(prefix string, content string, format string) {
+		content, _ = substituteVars(content, lt.vars)
+		valid, err, warn := IsValidLogContent(content, format)
+		for _, e := range err {
+			errors = append(errors, prefix+e)
+		}
+		for _, w := range warn {
+			warnings = append(warnings, prefix+w)
+		}
+		if !valid {
+			validTest = false
+		}
+	}
+
+	if !lt.IsSequence() {
+		if logData, err := os.ReadFile(lt.GetLogFilePath()); err == nil {
+			check("", string(logData), lt.GetFormat())
+		}
+		return validTest, errors, warnings
+	}
+
+	for i, step := range lt.Sequence {
+		format := step.Format
+		if format == "" {
+			format = lt.GetFormat()
+		}
+
+		prefix := "Step " + strconv.Itoa(i+1) + ": "
+		if step.LogFilePath == "" {
+			check(prefix, step.Log, format)
+		} else if logData, err := os.ReadFile(step.LogFilePath); err == nil {
+			check(prefix, string(logData), format)
+		}
+	}
+
+	return validTest, errors, warnings
+}
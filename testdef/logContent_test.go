@@ -0,0 +1,109 @@
+package testdef
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_IsValidLogContent(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		format       string
+		wantValid    bool
+		wantErrors   int
+		wantWarnings int
+	}{
+		{name: "Valid syslog", content: "Mar  5 13:49:34 ip-10-0-0-10 sshd[1602]: Invalid user bob\n", format: "syslog", wantValid: true},
+		{name: "Valid syslog zero padded day", content: "Mar 05 13:49:34 ip-10-0-0-10 sshd[1602]: Invalid user bob", format: "syslog", wantValid: true},
+		{name: "Valid syslog ISO8601 with priority", content: "<34>1 2024-03-05T13:49:34.000Z host app - - msg", format: "syslog", wantValid: true},
+		{name: "Valid syslog without header", content: "ossec: Manager started.", format: "syslog", wantValid: true, wantWarnings: 1},
+		{name: "Valid JSON", content: `{"srcip": "10.0.0.1"}` + "\r\n", format: "json", wantValid: true},
+		{name: "Valid eventchannel", content: `{"win": {"system": {"eventID": "4625"}}}`, format: "eventchannel", wantValid: true},
+		{name: "Valid eventchannel without win", content: `{"system": {}}`, format: "eventchannel", wantValid: true, wantWarnings: 1},
+		{name: "Valid multi-line", content: "line one\nline two\nline three\n", format: "multi-line:3", wantValid: true},
+		{name: "Valid other format", content: "Mar  5 13:49:34 squid log", format: "squid", wantValid: true},
+
+		{name: "Invalid JSON", content: `{"srcip": }`, format: "json", wantValid: false, wantErrors: 1},
+		{name: "Invalid eventchannel not an object", content: `["win"]`, format: "eventchannel", wantValid: false, wantErrors: 1},
+		{name: "Invalid eventchannel plain text", content: "An account failed to log on.", format: "eventchannel", wantValid: false, wantErrors: 1},
+		{name: "Invalid multi-line without format", content: "Mar  5 13:49:34 host a: one\nMar  5 13:49:34 host a: two", format: "syslog", wantValid: false, wantErrors: 1},
+		{name: "Invalid pretty printed JSON", content: "{\n\"srcip\": \"10.0.0.1\"\n}", format: "json", wantValid: false, wantErrors: 1},
+		{name: "Invalid multi-line count", content: "line one\nline two\n", format: "multi-line:3", wantValid: false, wantErrors: 1},
+		{name: "Invalid event too large", content: "Mar  5 13:49:34 host a: " + strings.Repeat("x", maxEventSize), format: "syslog", wantValid: false, wantErrors: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			valid, errors, warnings := IsValidLogContent(tt.content, tt.format)
+			if valid != tt.wantValid {
+				t.Errorf("IsValidLogContent() valid = %v, want %v (errors: %v)", valid, tt.wantValid, errors)
+			}
+			if len(errors) != tt.wantErrors {
+				t.Errorf("IsValidLogContent() errors = %v, want %d", errors, tt.wantErrors)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("IsValidLogContent() warnings = %v, want %d", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func Test_getMultiLineCount(t *testing.T) {
+	tests := []struct {
+		format string
+		want   int
+		wantOk bool
+	}{
+		{format: "multi-line:3", want: 3, wantOk: true},
+		{format: "multi-line:1", want: 1, wantOk: true},
+
+		{format: "multi-line", wantOk: false},
+		{format: "multi-line:0", wantOk: false},
+		{format: "multi-line:abc", wantOk: false},
+		{format: "syslog", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+			got, ok := getMultiLineCount(tt.format)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("getMultiLineCount() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_LogTest_validateLogContent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "event.json")
+	if err := os.WriteFile(path, []byte(`{"port": ${port}}`+"\n"), 0600); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+
+	// Placeholders are filled in before the content is checked
+	test := LogTest{LogFilePath: path, Format: "json", vars: map[string]string{"port": "22"}}
+	if valid, errors, _ := test.validateLogContent(); !valid {
+		t.Errorf("validateLogContent() errors = %v, want none", errors)
+	}
+
+	sequence := LogTest{Format: "syslog", Sequence: []SequenceStep{
+		{Log: "Mar  5 13:49:34 host sshd[1602]: Invalid user bob"},
+		{Log: "first\nsecond"},
+		{LogFilePath: path, Format: "eventchannel"},
+		{LogFilePath: filepath.Join(dir, "missing.log")},
+	}}
+	valid, errors, _ := sequence.validateLogContent()
+	if valid {
+		t.Errorf("validateLogContent() valid = true, want false")
+	}
+	want := []string{
+		"Step 2: Log should only have one line, use the multi-line:N format for logs that span lines",
+		"Step 3: Log is not a valid eventchannel JSON object: invalid character '$' looking for beginning of value",
+	}
+	if strings.Join(errors, "\n") != strings.Join(want, "\n") {
+		t.Errorf("validateLogContent() errors = %v, want %v", errors, want)
+	}
+}
@@ -0,0 +1,771 @@
+// Package testdef holds the model of the test definitions
+// and loads and validates them from a test tree.
+package testdef
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TestGroup struct {
+	// Default Timestamp for every test in the file
+	Timestamp string `json:"Timestamp"`
+
+	Tests []LogTest `json:"Tests"`
+}
+
+type LogTest struct {
+	// Identifies the test across runs. Defaults to the path of
+	// the definition file and the position of the test in it.
+	// UUID is the old name of the field and is still read.
+	ID              string            `json:"ID"`
+	UUID            string            `json:"UUID"`
+	Version         string            `json:"Version"`
+	RuleID          string            `json:"RuleID"`
+	RuleLevel       string            `json:"RuleLevel"`
+	RuleDescription string            `json:"RuleDescription"`
+	LogFilePath     string            `json:"LogFilePath"`
+	Format          string            `json:"Format"`
+	Decoder         map[string]string `json:"Decoder"`
+	Predecoder      map[string]string `json:"Predecoder"`
+	TestDescription string            `json:"TestDescription"`
+
+	// Optional fields that are set with setOptionalFields
+	Data      map[string]interface{} `json:"Data"`
+	Expect    map[string]interface{} `json:"Expect"`
+	Timestamp string                 `json:"Timestamp"`
+
+	// Seconds to wait for the logtest response before the
+	// test fails. Replaces the -o timeout for this test.
+	Timeout float64 `json:"Timeout"`
+
+	// Markers. Skip and ExpectFail give the reason, such
+	// as a ticket, and Only runs the marked tests alone.
+	Skip       string `json:"Skip"`
+	ExpectFail string `json:"ExpectFail"`
+	Only       bool   `json:"Only"`
+
+	// The events of a sequence test. Set by NewSequenceTest.
+	Sequence []SequenceStep `json:"Sequence"`
+
+	// Values for the ${var} placeholders. Expanded
+	// into one test per case by expandTestCases.
+	Matrix map[string][]string `json:"Matrix"`
+	Cases  []testCase          `json:"Cases"`
+
+	// Where the test was defined. Set by loadTestDef.
+	DefPath  string `json:"-"`
+	DefIndex int    `json:"-"`
+
+	// The case of a parameterized test and its variables
+	caseName string
+	vars     map[string]string
+}
+
+func NewLogTest(Version string, RuleID string, RuleLevel string, RuleDescription string, LogFilePath string, Format string, Decoder map[string]string, Predecoder map[string]string, TestDescription string) (*LogTest, bool, []string, []string) {
+	lt := new(LogTest)
+
+	validTest := true // Want to print out all invalid parts of the test
+	errors := []string{}
+	warnings := []string{}
+
+	// Version
+	valid, err, warn := IsValidVersion(Version)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.Version = Version
+
+	// Rule ID
+	valid, err, warn = IsValidRuleID(RuleID)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.RuleID = RuleID
+
+	// Rule Level
+	valid, err, warn = IsValidRuleLevel(RuleLevel)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.RuleLevel = RuleLevel
+
+	// Rule Description
+	valid, err, warn = IsValidRuleDescription(RuleDescription)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.RuleDescription = RuleDescription
+
+	// Log File Path
+	valid, err, warn = IsValidLogFilePath(LogFilePath)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.LogFilePath = LogFilePath
+
+	// Format
+	valid, err, warn = IsValidFormat(Format)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.Format = Format
+
+	// Decoder
+	valid, err, warn = IsValidDecoder(Decoder)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.Decoder = Decoder
+
+	// Predecoder
+	valid, err, warn = IsValidPredecoder(Predecoder)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.Predecoder = Predecoder
+
+	// Test Description
+	valid, err, warn = IsValidTestDescription(TestDescription)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.TestDescription = TestDescription
+
+	return lt, validTest, errors, warnings
+}
+
+// Validates and sets the optional fields of a test that
+// are not passed to NewLogTest. The raw test is the test
+// as it was decoded from the test definition file.
+func (lt *LogTest) setOptionalFields(raw LogTest) (bool, []string, []string) {
+	validTest := true
+	errors := []string{}
+	warnings := []string{}
+
+	// Data
+	valid, err, warn := IsValidData(raw.Data)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.Data = raw.Data
+
+	// Expect
+	valid, err, warn = IsValidExpect(raw.Expect)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.Expect = raw.Expect
+
+	// Timestamp
+	valid, err, warn = IsValidTimestamp(raw.Timestamp)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.Timestamp = raw.Timestamp
+
+	// ID
+	valid, err, warn = IsValidTestID(raw.getExplicitID())
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.ID = raw.getExplicitID()
+
+	// Timeout
+	valid, err, warn = IsValidTimeout(raw.Timeout)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.Timeout = raw.Timeout
+
+	// Markers
+	valid, err, warn = IsValidMarkers(raw)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.Skip = raw.Skip
+	lt.ExpectFail = raw.ExpectFail
+	lt.Only = raw.Only
+
+	return validTest, errors, warnings
+}
+
+func IsValidVersion(Version string) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	validVersions := map[string]struct{}{
+		"0.1": {},
+	}
+
+	// Empty Version is not an error but should generally be avoided
+	// as it will be assumed to be the latest version
+	if Version == "" {
+		warnings = append(warnings, "Version is empty using latest test version")
+		return true, errors, warnings
+	}
+
+	// Check if the version is valid
+	_, exists := validVersions[Version]
+
+	if !exists {
+		errors = append(errors, fmt.Sprintf("Invalid test version: %s", Version))
+		return false, errors, warnings
+	}
+
+	return true, errors, warnings
+}
+
+// This comes from the Wazuh documentation
+// Must be between 0 and 999999
+// See: https://documentation.wazuh.com/current/user-manual/ruleset/ruleset-xml-syntax/rules.html#rules-rule
+func IsValidRuleID(RuleID string) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	// Convert RuleID to int
+	RuleIDInt, err := strconv.Atoi(RuleID)
+
+	if err != nil {
+		errors = append(errors, "Invalid rule ID is not an integer")
+		return false, errors, warnings
+	}
+
+	if RuleIDInt < 0 {
+		errors = append(errors, "Invalid rule ID cannot be less than 0")
+		return false, errors, warnings
+	}
+
+	if RuleIDInt > 999999 {
+		errors = append(errors, "Invalid rule ID cannot be greater than 999999")
+		return false, errors, warnings
+	}
+
+	return true, errors, warnings
+}
+
+// This comes from the Wazuh documentation
+// Must be between 0 and 16
+// See: https://documentation.wazuh.com/current/user-manual/ruleset/ruleset-xml-syntax/rules.html#rules-rule
+func IsValidRuleLevel(level string) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	// Convert RuleLevel to int
+	levelInt, err := strconv.Atoi(level)
+
+	if err != nil {
+		errors = append(errors, "Invalid rule level is not an integer")
+		return false, errors, warnings
+	}
+
+	if levelInt < 0 {
+		// Cant be less than 0
+		errors = append(errors, "Invalid rule level cannot be less than 0")
+		return false, errors, warnings
+	}
+
+	if levelInt > 16 {
+		// Cant be greater than 16
+		errors = append(errors, "Invalid rule level cannot be greater than 16")
+		return false, errors, warnings
+	}
+
+	return true, errors, warnings
+}
+
+// Check that the rule description is not empty
+// Generally, the rule description should have some content
+func IsValidRuleDescription(RuleDescription string) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	if RuleDescription == "" {
+		warnings = append(warnings, "Rule description is empty")
+		return true, errors, warnings
+	}
+
+	return true, errors, warnings
+}
+
+// Check that the log file path is not empty, file exists,
+// is readable, is not emtpy, and has only one line
+func IsValidLogFilePath(LogFilePath string) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	// Check if the log file path is empty
+	if LogFilePath == "" || len(LogFilePath) == 0 {
+		errors = append(errors, "Log file path is empty")
+		return false, errors, warnings
+	}
+
+	// Check if the log file exists
+	_, err := os.Stat(LogFilePath)
+	if err != nil {
+		errors = append(errors, "Log file does not exist")
+		return false, errors, warnings
+	}
+
+	// Check if the log file is readable
+	file, err := os.Open(LogFilePath)
+	if err != nil {
+		errors = append(errors, "Log file is not readable")
+		return false, errors, warnings
+	}
+
+	// Check if the log file is empty
+	stat, err := file.Stat()
+	if err != nil {
+		errors = append(errors, "Error reading log file size")
+		return false, errors, warnings
+	}
+
+	if stat.Size() == 0 {
+		errors = append(errors, "Log file is empty")
+		return false, errors, warnings
+	}
+
+	// The lines and contents of the log are checked
+	// against the format by IsValidLogContent
+	return true, errors, warnings
+}
+
+// Reads a file and counts the number of lines in it
+// up to a maximum of 2 lines. If the file has more than
+// 1 line, it returns 2. If the file has 1 line, it returns 1.
+// If the file has no lines, it returns 0.
+func fileHasOneLine(r io.Reader) (bool, error) {
+	buf := make([]byte, 1024)
+	count := 0
+	lineSep := []byte{'\n'}
+	hasData := false
+
+	for {
+		c, err := r.Read(buf)
+		if c > 0 {
+			hasData = true
+			count += bytes.Count(buf[:c], lineSep)
+
+			// If we've counted 2 or more lines, return 2
+			if count >= 2 {
+				return false, nil
+			}
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				// If the file has data but no newline characters, it's considered one line
+				if hasData && count == 0 {
+					return true, nil
+				}
+				return count == 1, nil
+			}
+			return count == 1, err
+		}
+	}
+}
+
+func IsValidFormat(format string) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	// See below `log_format` in the API reference:
+	// https://documentation.wazuh.com/current/user-manual/api/reference.html#operation/api.controllers.logtest_controller.run_logtest_tool
+	validLogTypes := []string{
+		"syslog",
+		"json",
+		"snort-full",
+		"squid",
+		"eventlog",
+		"eventchannel",
+		"audit",
+		"mysql_log",
+		"postgresql_log",
+		"nmapg",
+		"iis",
+		"command",
+		"full_command",
+		"djb-multilog",
+		"multi-line",
+	}
+
+	if format == "" {
+		errors = append(errors, "Invalid format is empty")
+		return false, errors, warnings
+	}
+
+	// multi-line:N sends N lines as a single event
+	if strings.HasPrefix(format, "multi-line:") {
+		if _, ok := getMultiLineCount(format); !ok {
+			errors = append(errors, fmt.Sprintf("Log format: %s is invalid, expected multi-line:N with N greater than 0", format))
+			return false, errors, warnings
+		}
+		return true, errors, warnings
+	}
+
+	if format == "multi-line" {
+		warnings = append(warnings, "Log format multi-line should set the number of lines, e.g. multi-line:3")
+	}
+
+	// Check if the format is valid
+	valid := false
+	for _, logType := range validLogTypes {
+		if format == logType {
+			valid = true
+			break
+		}
+	}
+
+	if !valid {
+		errors = append(errors, fmt.Sprintf("Log format: %s is invalid", format))
+		return false, errors, warnings
+	}
+
+	return true, errors, warnings
+}
+
+// Checks if any of the decoder values are empty
+func IsValidDecoder(decoder map[string]string) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	// Iterate over map and check if any of the values are empty
+	// This is generally a mistake but will not cause a test to
+	// break Wazuh Log Test
+	for key, value := range decoder {
+		if value == "" {
+			warnings = append(warnings, "Decoder value for key "+key+" is empty")
+			continue
+		}
+	}
+
+	return true, errors, warnings
+}
+
+// Checks if any of the predecoder values are empty
+func IsValidPredecoder(predecoder map[string]string) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	// Iterate over map and check if any of the values are empty
+	// This is generally a mistake but will not cause a test to
+	// break Wazuh Log Test
+	for key, value := range predecoder {
+		if value == "" {
+			warnings = append(warnings, "Predecoder value for key "+key+" is empty")
+		}
+	}
+
+	return true, errors, warnings
+}
+
+// Checks that none of the data keys are empty and
+// warns about empty values. Keys may be dotted paths
+// (e.g. win.eventdata.targetUserName) but cannot start
+// or end with a dot.
+func IsValidData(data map[string]interface{}) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+	valid := true
+
+	for key, value := range data {
+		if key == "" {
+			errors = append(errors, "Data key is empty")
+			valid = false
+			continue
+		}
+
+		if strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") {
+			errors = append(errors, "Data key "+key+" is not a valid path")
+			valid = false
+			continue
+		}
+
+		if value == "" {
+			warnings = append(warnings, "Data value for key "+key+" is empty")
+		}
+	}
+
+	return valid, errors, warnings
+}
+
+// Checks that every selector and matcher in the
+// Expect block can be parsed
+func IsValidExpect(expect map[string]interface{}) (bool, []string, []string) {
+	warnings := []string{}
+
+	_, errors := parseExpectations(expect)
+	if len(errors) > 0 {
+		return false, errors, warnings
+	}
+
+	return true, errors, warnings
+}
+
+// The timestamp of the log can be rewritten to now
+// or to a fixed time
+func IsValidTimestamp(timestamp string) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	if timestamp == "" || timestamp == timestampNow || timestamp == timestampOff {
+		return true, errors, warnings
+	}
+
+	if _, ok := parseFixedTimestamp(timestamp); !ok {
+		errors = append(errors, "Invalid timestamp: "+timestamp+" (expected now, off or a time like 2024-03-05T13:49:34Z)")
+		return false, errors, warnings
+	}
+
+	return true, errors, warnings
+}
+
+// IDs are matched by -run and used as keys in
+// baselines so they cannot contain whitespace
+func IsValidTestID(id string) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	if strings.ContainsAny(id, " \t\r\n") {
+		errors = append(errors, "Invalid ID contains whitespace: "+id)
+		return false, errors, warnings
+	}
+
+	return true, errors, warnings
+}
+
+// A zero timeout waits as long as the request does
+func IsValidTimeout(timeout float64) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	if timeout < 0 {
+		errors = append(errors, fmt.Sprintf("Invalid timeout: %g, it cannot be negative", timeout))
+		return false, errors, warnings
+	}
+
+	return true, errors, warnings
+}
+
+// A test is either skipped or expected to fail
+func IsValidMarkers(raw LogTest) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	if raw.Skip != "" && raw.ExpectFail != "" {
+		errors = append(errors, "Skip and ExpectFail cannot both be set")
+		return false, errors, warnings
+	}
+
+	if raw.Only && raw.Skip != "" {
+		warnings = append(warnings, "Test is marked Only but is skipped")
+	}
+
+	return true, errors, warnings
+}
+
+// The ID set in the test definition, if any
+func (lt LogTest) getExplicitID() string {
+	if lt.ID != "" {
+		return lt.ID
+	}
+	return lt.UUID
+}
+
+// Checks if test description is empty
+func IsValidTestDescription(TestDescription string) (bool, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	// Will not cause a test to break Wazuh Log Test
+	// but should generally be avoided
+	if TestDescription == "" {
+		warnings = append(warnings, "Test description is empty")
+	}
+
+	return true, errors, warnings
+}
+
+func (lt *LogTest) GetTimeout() time.Duration {
+	return time.Duration(lt.Timeout * float64(time.Second))
+}
+
+// A human readable name for the test used in reports
+func (lt *LogTest) GetDisplayName() string {
+	if lt.TestDescription != "" {
+		return lt.TestDescription
+	}
+
+	if lt.DefPath != "" {
+		return lt.DefPath + " test #" + strconv.Itoa(lt.DefIndex+1)
+	}
+
+	return "RuleID " + lt.RuleID + " test"
+}
+
+// Sequence tests are run on a dedicated session and
+// have their expectations set on each step
+func (lt *LogTest) IsSequence() bool {
+	return len(lt.Sequence) > 0
+}
+
+// Describes what the test asserts in result headers
+func (lt *LogTest) GetRuleLabel() string {
+	if len(lt.Sequence) == 1 {
+		return "Sequence: 1 step"
+	}
+	if lt.IsSequence() {
+		return "Sequence: " + strconv.Itoa(len(lt.Sequence)) + " steps"
+	}
+	return "RuleID: " + lt.RuleID
+}
+
+// Every log file the test reads
+func (lt *LogTest) GetLogFilePaths() []string {
+	if !lt.IsSequence() {
+		return []string{lt.LogFilePath}
+	}
+
+	var paths []string
+	for _, step := range lt.Sequence {
+		if step.LogFilePath != "" {
+			paths = append(paths, step.LogFilePath)
+		}
+	}
+	return paths
+}
+
+// Every rule ID the test expects to fire
+func (lt *LogTest) GetAssertedRuleIDs() []string {
+	if !lt.IsSequence() {
+		return []string{lt.RuleID}
+	}
+
+	var ruleIDs []string
+	for _, step := range lt.Sequence {
+		if step.RuleID != "" {
+			ruleIDs = append(ruleIDs, step.RuleID)
+		}
+	}
+	return ruleIDs
+}
+
+func (lt *LogTest) GetRuleID() string {
+	return lt.RuleID
+}
+
+func (lt *LogTest) GetTestDescription() string {
+	return lt.TestDescription
+}
+
+func (lt *LogTest) GetLogFilePath() string {
+	return lt.LogFilePath
+}
+
+func (lt *LogTest) GetFormat() string {
+	return lt.Format
+}
+
+func (lt *LogTest) GetRuleDescription() string {
+	return lt.RuleDescription
+}
+
+func (lt *LogTest) GetRuleLevel() string {
+	return lt.RuleLevel
+}
+
+func (lt *LogTest) GetDecoder() map[string]string {
+	return lt.Decoder
+}
+
+func (lt *LogTest) GetPredecoder() map[string]string {
+	return lt.Predecoder
+}
+
+func (lt *LogTest) GetData() map[string]interface{} {
+	return lt.Data
+}
+
+func (lt *LogTest) GetExpect() map[string]interface{} {
+	return lt.Expect
+}
+
+// What the test checks, in the same shape as the test
+// definition
+func (lt *LogTest) GetExpectations() map[string]interface{} {
+	expected := map[string]interface{}{}
+
+	if lt.IsSequence() {
+		var steps []map[string]interface{}
+		for _, step := range lt.Sequence {
+			fields := map[string]interface{}{}
+			addExpectations(fields, step.RuleID, step.RuleLevel, step.RuleDescription, step.Decoder, step.Predecoder, step.Data, step.Expect)
+			if step.Repeat > 1 {
+				fields["Repeat"] = step.Repeat
+			}
+			steps = append(steps, fields)
+		}
+		expected["Sequence"] = steps
+		return expected
+	}
+
+	addExpectations(expected, lt.RuleID, lt.RuleLevel, lt.RuleDescription, lt.Decoder, lt.Predecoder, lt.Data, lt.Expect)
+	return expected
+}
+
+// Adds the expectations that are set
+func addExpectations(fields map[string]interface{}, ruleID string, ruleLevel string, ruleDescription string, decoder map[string]string, predecoder map[string]string, data map[string]interface{}, expect map[string]interface{}) {
+	for key, value := range map[string]string{"RuleID": ruleID, "RuleLevel": ruleLevel, "RuleDescription": ruleDescription} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	if len(decoder) > 0 {
+		fields["Decoder"] = decoder
+	}
+	if len(predecoder) > 0 {
+		fields["Predecoder"] = predecoder
+	}
+	if len(data) > 0 {
+		fields["Data"] = data
+	}
+	if len(expect) > 0 {
+		fields["Expect"] = expect
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package testdef
 
 import (
 	"fmt"
@@ -10,7 +10,7 @@ import (
 	"testing"
 )
 
-func Test_isValidVersion(t *testing.T) {
+func Test_IsValidVersion(t *testing.T) {
 	type args struct {
 		Version string
 	}
@@ -34,21 +34,21 @@ func Test_isValidVersion(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 			t.Logf(fmt.Sprintf("%+v", tt))
-			got, got1, got2 := isValidVersion(tt.args.Version)
+			got, got1, got2 := IsValidVersion(tt.args.Version)
 			if got != tt.want {
-				t.Errorf("isValidVersion() got = %v, want %v", got, tt.want)
+				t.Errorf("IsValidVersion() got = %v, want %v", got, tt.want)
 			}
 			if !reflect.DeepEqual(got1, tt.want1) {
-				t.Errorf("isValidVersion() got1 = %v, want %v", got1, tt.want1)
+				t.Errorf("IsValidVersion() got1 = %v, want %v", got1, tt.want1)
 			}
 			if !reflect.DeepEqual(got2, tt.want2) {
-				t.Errorf("isValidVersion() got2 = %v, want %v", got2, tt.want2)
+				t.Errorf("IsValidVersion() got2 = %v, want %v", got2, tt.want2)
 			}
 		})
 	}
 }
 
-func Fuzz_isValidVersion(f *testing.F) {
+func Fuzz_IsValidVersion(f *testing.F) {
 	// Adding a string instead of an integer
 	f.Add("10167")
 	f.Fuzz(func(t *testing.T, v string) {
@@ -58,23 +58,23 @@ func Fuzz_isValidVersion(f *testing.F) {
 			}
 		}()
 
-		isValidVersion(v)
+		IsValidVersion(v)
 	})
 }
 
-func Benchmark_isValidVersion_validVersion(b *testing.B) {
+func Benchmark_IsValidVersion_validVersion(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		isValidVersion("0.1")
+		IsValidVersion("0.1")
 	}
 }
 
-func Benchmark_isValidVersion_invalidVersion(b *testing.B) {
+func Benchmark_IsValidVersion_invalidVersion(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		isValidVersion(strconv.Itoa(i))
+		IsValidVersion(strconv.Itoa(i))
 	}
 }
 
-func Test_isValidRuleID(t *testing.T) {
+func Test_IsValidRuleID(t *testing.T) {
 	type args struct {
 		RuleID string
 	}
@@ -98,21 +98,21 @@ func Test_isValidRuleID(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got, got1, got2 := isValidRuleID(tt.args.RuleID)
+			got, got1, got2 := IsValidRuleID(tt.args.RuleID)
 			if got != tt.want {
-				t.Errorf("isValidRuleID() got = %v, want %v", got, tt.want)
+				t.Errorf("IsValidRuleID() got = %v, want %v", got, tt.want)
 			}
 			if !reflect.DeepEqual(got1, tt.want1) {
-				t.Errorf("isValidRuleID() got1 = %v, want %v", got1, tt.want1)
+				t.Errorf("IsValidRuleID() got1 = %v, want %v", got1, tt.want1)
 			}
 			if !reflect.DeepEqual(got2, tt.want2) {
-				t.Errorf("isValidRuleID() got2 = %v, want %v", got2, tt.want2)
+				t.Errorf("IsValidRuleID() got2 = %v, want %v", got2, tt.want2)
 			}
 		})
 	}
 }
 
-func Fuzz_isValidRuleID(f *testing.F) {
+func Fuzz_IsValidRuleID(f *testing.F) {
 	// Adding a string instead of an integer
 	f.Add("10167")
 	f.Fuzz(func(t *testing.T, id string) {
@@ -122,22 +122,22 @@ func Fuzz_isValidRuleID(f *testing.F) {
 			}
 		}()
 
-		isValidRuleID(id)
+		IsValidRuleID(id)
 	})
 }
-func Benchmark_isValidRuleID_validRuleID(b *testing.B) {
+func Benchmark_IsValidRuleID_validRuleID(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		isValidRuleID("999")
+		IsValidRuleID("999")
 	}
 }
 
-func Benchmark_isValidRuleID_invalidRuleID(b *testing.B) {
+func Benchmark_IsValidRuleID_invalidRuleID(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		isValidRuleID(strconv.Itoa(i))
+		IsValidRuleID(strconv.Itoa(i))
 	}
 }
 
-func Test_isValidRuleLevel(t *testing.T) {
+func Test_IsValidRuleLevel(t *testing.T) {
 	type args struct {
 		level string
 	}
@@ -162,9 +162,9 @@ func Test_isValidRuleLevel(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got, got1, got2 := isValidRuleLevel(tt.args.level)
+			got, got1, got2 := IsValidRuleLevel(tt.args.level)
 			if got != tt.want {
-				t.Errorf("isValidRuleLevel() got = %v, want %v", got, tt.want)
+				t.Errorf("IsValidRuleLevel() got = %v, want %v", got, tt.want)
 			}
 
 			if len(tt.want1) > 0 { // If we are expecting errors
@@ -182,13 +182,13 @@ func Test_isValidRuleLevel(t *testing.T) {
 			}
 
 			if !reflect.DeepEqual(got2, tt.want2) {
-				t.Errorf("isValidRuleLevel() got2 = %v, want %v", got2, tt.want2)
+				t.Errorf("IsValidRuleLevel() got2 = %v, want %v", got2, tt.want2)
 			}
 		})
 	}
 }
 
-func Fuzz_isValidRuleLevel(f *testing.F) {
+func Fuzz_IsValidRuleLevel(f *testing.F) {
 	examples := []string{"0", "16", "1000", "-373"}
 	for _, ex := range examples {
 		f.Add(ex)
@@ -201,23 +201,23 @@ func Fuzz_isValidRuleLevel(f *testing.F) {
 			}
 		}()
 
-		isValidRuleLevel(l)
+		IsValidRuleLevel(l)
 	})
 }
 
-func Benchmark_isValidRuleLevel_validRuleLevel(b *testing.B) {
+func Benchmark_IsValidRuleLevel_validRuleLevel(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		isValidRuleLevel("15")
+		IsValidRuleLevel("15")
 	}
 }
 
-func Benchmark_isValidRuleLevel_invalidRuleLevel(b *testing.B) {
+func Benchmark_IsValidRuleLevel_invalidRuleLevel(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		isValidRuleLevel(strconv.Itoa(i))
+		IsValidRuleLevel(strconv.Itoa(i))
 	}
 }
 
-func Test_isValidRuleDescription(t *testing.T) {
+func Test_IsValidRuleDescription(t *testing.T) {
 	type args struct {
 		RuleDescription string
 	}
@@ -235,21 +235,21 @@ func Test_isValidRuleDescription(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got, got1, got2 := isValidRuleDescription(tt.args.RuleDescription)
+			got, got1, got2 := IsValidRuleDescription(tt.args.RuleDescription)
 			if got != tt.want {
-				t.Errorf("isValidRuleDescription() got = %v, want %v", got, tt.want)
+				t.Errorf("IsValidRuleDescription() got = %v, want %v", got, tt.want)
 			}
 			if !reflect.DeepEqual(got1, tt.want1) {
-				t.Errorf("isValidRuleDescription() got1 = %v, want %v", got1, tt.want1)
+				t.Errorf("IsValidRuleDescription() got1 = %v, want %v", got1, tt.want1)
 			}
 			if !reflect.DeepEqual(got2, tt.want2) {
-				t.Errorf("isValidRuleDescription() got2 = %v, want %v", got2, tt.want2)
+				t.Errorf("IsValidRuleDescription() got2 = %v, want %v", got2, tt.want2)
 			}
 		})
 	}
 }
 
-func Fuzz_isValidRuleDescription(f *testing.F) {
+func Fuzz_IsValidRuleDescription(f *testing.F) {
 	examples := []string{"This is a test description.", "It can have unicode 👍Ư", "More unicode ؿ", "\"\"\\"}
 	for _, ex := range examples {
 		f.Add(ex)
@@ -262,23 +262,23 @@ func Fuzz_isValidRuleDescription(f *testing.F) {
 			}
 		}()
 
-		isValidRuleDescription(d)
+		IsValidRuleDescription(d)
 	})
 }
 
-func Benchmark_isValidRuleDescription_validDescription(b *testing.B) {
+func Benchmark_IsValidRuleDescription_validDescription(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		isValidRuleDescription("This is a valid rule description")
+		IsValidRuleDescription("This is a valid rule description")
 	}
 }
 
-func Benchmark_isValidRuleDescription_validDescriptionWithWarn(b *testing.B) {
+func Benchmark_IsValidRuleDescription_validDescriptionWithWarn(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		isValidRuleDescription("")
+		IsValidRuleDescription("")
 	}
 }
 
-func Test_isValidLogFilePath(t *testing.T) {
+func Test_IsValidLogFilePath(t *testing.T) {
 	// Create a file for testing purposes
 	files := map[string]string{
 		"emptyFileName":    "./empty.log",
@@ -355,26 +355,26 @@ func Test_isValidLogFilePath(t *testing.T) {
 		{name: "Invalid empty log file", args: args{files["emptyFileName"]}, want: false, want1: []string{"Log file is empty"}, want2: []string{}},
 		{name: "Invalid empty log file path", args: args{""}, want: false, want1: []string{"Log file path is empty"}, want2: []string{}},
 		{name: "Invalid non-existent log file path", args: args{"./i-dont-exist.log"}, want: false, want1: []string{"Log file does not exist"}, want2: []string{}},
-		{name: "Invalid multi-line log file", args: args{files["twoLineFileName"]}, want: false, want1: []string{"Log file should only have one line"}, want2: []string{}},
+		{name: "Valid multi-line log file", args: args{files["twoLineFileName"]}, want: true, want1: []string{}, want2: []string{}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got, got1, got2 := isValidLogFilePath(tt.args.LogFilePath)
+			got, got1, got2 := IsValidLogFilePath(tt.args.LogFilePath)
 			if got != tt.want {
-				t.Errorf("isValidLogFilePath() got = %v, want %v", got, tt.want)
+				t.Errorf("IsValidLogFilePath() got = %v, want %v", got, tt.want)
 			}
 			if !reflect.DeepEqual(got1, tt.want1) {
-				t.Errorf("isValidLogFilePath() got1 = %v, want %v", got1, tt.want1)
+				t.Errorf("IsValidLogFilePath() got1 = %v, want %v", got1, tt.want1)
 			}
 			if !reflect.DeepEqual(got2, tt.want2) {
-				t.Errorf("isValidLogFilePath() got2 = %v, want %v", got2, tt.want2)
+				t.Errorf("IsValidLogFilePath() got2 = %v, want %v", got2, tt.want2)
 			}
 		})
 	}
 }
 
-func Fuzz_isValidLogFilePath(f *testing.F) {
+func Fuzz_IsValidLogFilePath(f *testing.F) {
 	examples := []string{"./log.log", "./wazuh.log", "./file", "/tmp/full/file/path"}
 	for _, ex := range examples {
 		f.Add(ex)
@@ -387,13 +387,13 @@ func Fuzz_isValidLogFilePath(f *testing.F) {
 			}
 		}()
 
-		isValidLogFilePath(f)
+		IsValidLogFilePath(f)
 	})
 }
 
-func Benchmark_isValidLogFilePath_invalidLogFiles(b *testing.B) {
+func Benchmark_IsValidLogFilePath_invalidLogFiles(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		isValidLogFilePath(strconv.Itoa(i))
+		IsValidLogFilePath(strconv.Itoa(i))
 	}
 }
 
@@ -501,7 +501,7 @@ func Test_fileHasOneLine(t *testing.T) {
 	}
 }
 
-func Test_isValidFormat(t *testing.T) {
+func Test_IsValidFormat(t *testing.T) {
 	type args struct {
 		format string
 	}
@@ -516,18 +516,21 @@ func Test_isValidFormat(t *testing.T) {
 		{name: "Valid format syslog", args: args{"syslog"}, want: true, want1: []string{}, want2: []string{}},
 		{name: "Valid format json", args: args{"json"}, want: true, want1: []string{}, want2: []string{}},
 		{name: "Valid format audit", args: args{"audit"}, want: true, want1: []string{}, want2: []string{}},
+		{name: "Valid format multi-line:3", args: args{"multi-line:3"}, want: true, want1: []string{}, want2: []string{}},
+		{name: "Valid format multi-line without lines", args: args{"multi-line"}, want: true, want1: []string{}, want2: []string{"Log format multi-line should set the number of lines, e.g. multi-line:3"}},
 
 		// Invalid formats
 		{name: "Invalid format NON_VALID", args: args{"NON_VALID"}, want: false, want1: []string{"Log format: NON_VALID is not valid"}, want2: []string{}},
 		{name: "Invalid format unicode 🥝🥝🥝🥝", args: args{"🥝🥝🥝🥝"}, want: false, want1: []string{"Log format: 🥝🥝🥝🥝 is not valid"}, want2: []string{}},
 		{name: "Invalid empty format", args: args{""}, want: false, want1: []string{"Format is empty"}, want2: []string{}},
+		{name: "Invalid format multi-line:0", args: args{"multi-line:0"}, want: false, want1: []string{"Log format: multi-line:0 is invalid"}, want2: []string{}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got, got1, got2 := isValidFormat(tt.args.format)
+			got, got1, got2 := IsValidFormat(tt.args.format)
 			if got != tt.want {
-				t.Errorf("isValidFormat() got = %v, want %v", got, tt.want)
+				t.Errorf("IsValidFormat() got = %v, want %v", got, tt.want)
 			}
 
 			if len(tt.want1) > 0 { // If we are expecting errors
@@ -545,13 +548,13 @@ func Test_isValidFormat(t *testing.T) {
 			}
 
 			if !reflect.DeepEqual(got2, tt.want2) {
-				t.Errorf("isValidFormat() got2 = %v, want %v", got2, tt.want2)
+				t.Errorf("IsValidFormat() got2 = %v, want %v", got2, tt.want2)
 			}
 		})
 	}
 }
 
-func Fuzz_isValidFormat(f *testing.F) {
+func Fuzz_IsValidFormat(f *testing.F) {
 	examples := []string{"syslog", "audit", "multi-log", "bleh*bleh"}
 	for _, ex := range examples {
 		f.Add(ex)
@@ -564,17 +567,17 @@ func Fuzz_isValidFormat(f *testing.F) {
 			}
 		}()
 
-		isValidFormat(f)
+		IsValidFormat(f)
 	})
 }
 
-func Benchmark_isValidFormat_invalidFormats(b *testing.B) {
+func Benchmark_IsValidFormat_invalidFormats(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		isValidFormat(strconv.Itoa(i))
+		IsValidFormat(strconv.Itoa(i))
 	}
 }
 
-func Test_isValidDecoder(t *testing.T) {
+func Test_IsValidDecoder(t *testing.T) {
 	type args struct {
 		decoder map[string]string
 	}
@@ -594,28 +597,28 @@ func Test_isValidDecoder(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, got1, got2 := isValidDecoder(tt.args.decoder)
+			got, got1, got2 := IsValidDecoder(tt.args.decoder)
 			if got != tt.want {
-				t.Errorf("isValidDecoder() got = %v, want %v", got, tt.want)
+				t.Errorf("IsValidDecoder() got = %v, want %v", got, tt.want)
 			}
 			if !reflect.DeepEqual(got1, tt.want1) {
-				t.Errorf("isValidDecoder() got1 = %v, want %v", got1, tt.want1)
+				t.Errorf("IsValidDecoder() got1 = %v, want %v", got1, tt.want1)
 			}
 			if !reflect.DeepEqual(got2, tt.want2) {
-				t.Errorf("isValidDecoder() got2 = %v, want %v", got2, tt.want2)
+				t.Errorf("IsValidDecoder() got2 = %v, want %v", got2, tt.want2)
 			}
 		})
 	}
 }
 
-func Benchmark_isValidDecoder(b *testing.B) {
+func Benchmark_IsValidDecoder(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		num := strconv.Itoa(i)
-		isValidDecoder(map[string]string{num: num})
+		IsValidDecoder(map[string]string{num: num})
 	}
 }
 
-func Test_isValidPredecoder(t *testing.T) {
+func Test_IsValidPredecoder(t *testing.T) {
 	type args struct {
 		predecoder map[string]string
 	}
@@ -636,28 +639,28 @@ func Test_isValidPredecoder(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got, got1, got2 := isValidPredecoder(tt.args.predecoder)
+			got, got1, got2 := IsValidPredecoder(tt.args.predecoder)
 			if got != tt.want {
-				t.Errorf("isValidPredecoder() got = %v, want %v", got, tt.want)
+				t.Errorf("IsValidPredecoder() got = %v, want %v", got, tt.want)
 			}
 			if !reflect.DeepEqual(got1, tt.want1) {
-				t.Errorf("isValidPredecoder() got1 = %v, want %v", got1, tt.want1)
+				t.Errorf("IsValidPredecoder() got1 = %v, want %v", got1, tt.want1)
 			}
 			if !reflect.DeepEqual(got2, tt.want2) {
-				t.Errorf("isValidPredecoder() got2 = %v, want %v", got2, tt.want2)
+				t.Errorf("IsValidPredecoder() got2 = %v, want %v", got2, tt.want2)
 			}
 		})
 	}
 }
 
-func Benchmark_isValidPredecoder(b *testing.B) {
+func Benchmark_IsValidPredecoder(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		num := strconv.Itoa(i)
-		isValidPredecoder(map[string]string{num: num})
+		IsValidPredecoder(map[string]string{num: num})
 	}
 }
 
-func Test_isValidTestDescription(t *testing.T) {
+func Test_IsValidTestDescription(t *testing.T) {
 	type args struct {
 		TestDescription string
 	}
@@ -675,21 +678,21 @@ func Test_isValidTestDescription(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, got1, got2 := isValidTestDescription(tt.args.TestDescription)
+			got, got1, got2 := IsValidTestDescription(tt.args.TestDescription)
 			if got != tt.want {
-				t.Errorf("isValidTestDescription() got = %v, want %v", got, tt.want)
+				t.Errorf("IsValidTestDescription() got = %v, want %v", got, tt.want)
 			}
 			if !reflect.DeepEqual(got1, tt.want1) {
-				t.Errorf("isValidTestDescription() got1 = %v, want %v", got1, tt.want1)
+				t.Errorf("IsValidTestDescription() got1 = %v, want %v", got1, tt.want1)
 			}
 			if !reflect.DeepEqual(got2, tt.want2) {
-				t.Errorf("isValidTestDescription() got2 = %v, want %v", got2, tt.want2)
+				t.Errorf("IsValidTestDescription() got2 = %v, want %v", got2, tt.want2)
 			}
 		})
 	}
 }
 
-func Fuzz_isValidTestDescription(f *testing.F) {
+func Fuzz_IsValidTestDescription(f *testing.F) {
 	examples := []string{"syslog", "audit", "multi-log", "bleh*bleh", "This is a description🥝"}
 	for _, ex := range examples {
 		f.Add(ex)
@@ -702,13 +705,77 @@ func Fuzz_isValidTestDescription(f *testing.F) {
 			}
 		}()
 
-		isValidTestDescription(td)
+		IsValidTestDescription(td)
 	})
 }
 
-func Benchmark_isValidTestDescription(b *testing.B) {
+func Benchmark_IsValidTestDescription(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		isValidTestDescription(strconv.Itoa(i))
+		IsValidTestDescription(strconv.Itoa(i))
+	}
+}
+
+func Test_IsValidData(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  map[string]interface{}
+		want  bool
+		want1 []string
+		want2 []string
+	}{
+		{name: "Valid empty data", data: map[string]interface{}{}, want: true, want1: []string{}, want2: []string{}},
+		{name: "Valid nested path", data: map[string]interface{}{"win.system.eventID": float64(4625)}, want: true, want1: []string{}, want2: []string{}},
+		{name: "Valid but warn for empty value", data: map[string]interface{}{"srcip": ""}, want: true, want1: []string{}, want2: []string{"Data value for key srcip is empty"}},
+
+		{name: "Invalid empty key", data: map[string]interface{}{"": "value"}, want: false, want1: []string{"Data key is empty"}, want2: []string{}},
+		{name: "Invalid trailing dot", data: map[string]interface{}{"win.": "value"}, want: false, want1: []string{"Data key win. is not a valid path"}, want2: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, got1, got2 := IsValidData(tt.data)
+			if got != tt.want {
+				t.Errorf("IsValidData() got = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(got1, tt.want1) {
+				t.Errorf("IsValidData() got1 = %v, want %v", got1, tt.want1)
+			}
+			if !reflect.DeepEqual(got2, tt.want2) {
+				t.Errorf("IsValidData() got2 = %v, want %v", got2, tt.want2)
+			}
+		})
+	}
+}
+
+func Test_IsValidExpect(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect string
+		want   bool
+		want1  []string
+	}{
+		{name: "Valid empty expect", expect: `{}`, want: true, want1: []string{}},
+		{name: "Valid object value", expect: `{"decoder": {"name": "sshd"}}`, want: true, want1: []string{}},
+
+		{name: "Invalid empty selector", expect: `{"": 1}`, want: false, want1: []string{"Expect selector is empty"}},
+		{name: "Invalid unclosed bracket", expect: `{"rule.mitre.id[0": 1}`, want: false, want1: []string{"Expect selector rule.mitre.id[0 has an unclosed ["}},
+		{name: "Invalid bracket index", expect: `{"rule.mitre.id[x]": 1}`, want: false, want1: []string{"Expect selector rule.mitre.id[x] has an invalid index: x"}},
+		{name: "Invalid regex", expect: `{"full_log": {"regex": "("}}`, want: false, want1: []string{"Expect full_log: invalid regex: error parsing regexp: missing closing ): `(`"}},
+		{name: "Invalid exists type", expect: `{"rule.mail": {"exists": "yes"}}`, want: false, want1: []string{"Expect rule.mail: exists must be true or false"}},
+		{name: "Invalid absent with value", expect: `{"rule.mail": {"absent": true, "equals": false}}`, want: false, want1: []string{"Expect rule.mail: a value matcher cannot be combined with absent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			expect := decodeTestJSON(t, tt.expect).(map[string]interface{})
+			got, got1, _ := IsValidExpect(expect)
+			if got != tt.want {
+				t.Errorf("IsValidExpect() got = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(got1, tt.want1) {
+				t.Errorf("IsValidExpect() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
 	}
 }
 
@@ -1096,3 +1163,77 @@ func Test_NewLogTestInvalidFormat(t *testing.T) {
 		t.Errorf("Missing invalid format error")
 	}
 }
+
+func Test_IsValidTestID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{name: "Valid empty ID", id: "", want: true},
+		{name: "Valid ID", id: "ssh-brute-force", want: true},
+		{name: "Valid UUID", id: "9e1b8781-dfa5-4c87-b02d-3a97367decee", want: true},
+
+		{name: "Invalid ID with spaces", id: "ssh brute force", want: false},
+		{name: "Invalid ID with a tab", id: "ssh\tbrute", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got, _, _ := IsValidTestID(tt.id); got != tt.want {
+				t.Errorf("IsValidTestID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_IsValidMarkers(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          LogTest
+		want         bool
+		wantWarnings int
+	}{
+		{name: "Valid no markers", raw: LogTest{}, want: true},
+		{name: "Valid skip", raw: LogTest{Skip: "Broken upstream"}, want: true},
+		{name: "Valid expected failure", raw: LogTest{ExpectFail: "ticket-123"}, want: true},
+		{name: "Valid only", raw: LogTest{Only: true, ExpectFail: "ticket-123"}, want: true},
+		{name: "Valid only and skip", raw: LogTest{Only: true, Skip: "Broken upstream"}, want: true, wantWarnings: 1},
+
+		{name: "Invalid skip and expected failure", raw: LogTest{Skip: "Broken upstream", ExpectFail: "ticket-123"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, _, warnings := IsValidMarkers(tt.raw)
+			if got != tt.want {
+				t.Errorf("IsValidMarkers() = %v, want %v", got, tt.want)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("IsValidMarkers() warnings = %v, want %d", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func Test_IsValidTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout float64
+		want    bool
+	}{
+		{name: "Valid no timeout", timeout: 0, want: true},
+		{name: "Valid fraction of a second", timeout: 0.5, want: true},
+		{name: "Valid seconds", timeout: 10, want: true},
+
+		{name: "Invalid negative timeout", timeout: -1, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got, _, _ := IsValidTimeout(tt.timeout); got != tt.want {
+				t.Errorf("IsValidTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
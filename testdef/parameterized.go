@@ -0,0 +1,279 @@
+package testdef
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A named set of values for the ${var} placeholders of a test
+type testCase struct {
+	Name string            `json:"Name"`
+	Vars map[string]string `json:"Vars"`
+}
+
+// Upper bound on the tests a single definition expands
+// to, to catch matrices that grew by accident
+const maxTestCases = 1000
+
+var (
+	varNamePattern     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	placeholderPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_.]*)\}`)
+)
+
+// Expands a test with Cases and/or a Matrix into one test per
+// combination. Every case is combined with every row of the
+// matrix. Tests without either are returned as is.
+func expandTestCases(raw LogTest) ([]LogTest, []string, []string) {
+	errors := []string{}
+	warnings := []string{}
+
+	if len(raw.Matrix) == 0 && len(raw.Cases) == 0 {
+		return []LogTest{raw}, errors, warnings
+	}
+
+	// Matrix
+	var names []string
+	for name, values := range raw.Matrix {
+		names = append(names, name)
+		if !varNamePattern.MatchString(name) {
+			errors = append(errors, "Invalid matrix variable name: "+name)
+		}
+		if len(values) == 0 {
+			errors = append(errors, "Matrix variable "+name+" has no values")
+		}
+	}
+	sort.Strings(names)
+
+	// Cases
+	cases := raw.Cases
+	if len(cases) == 0 {
+		cases = []testCase{{}}
+	}
+	for i, c := range cases {
+		if len(raw.Cases) > 0 && len(c.Vars) == 0 {
+			errors = append(errors, "Case #"+strconv.Itoa(i+1)+" has no variables")
+		}
+		for name := range c.Vars {
+			if !varNamePattern.MatchString(name) {
+				errors = append(errors, "Invalid case variable name: "+name)
+			}
+			if _, ok := raw.Matrix[name]; ok {
+				errors = append(errors, "Variable "+name+" is set by both Cases and Matrix")
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return nil, errors, warnings
+	}
+
+	rows := matrixCombinations(raw.Matrix, names)
+	if len(cases)*len(rows) > maxTestCases {
+		errors = append(errors, fmt.Sprintf("Test expands to %d cases, the maximum is %d", len(cases)*len(rows), maxTestCases))
+		return nil, errors, warnings
+	}
+
+	var expanded []LogTest
+	seen := map[string]struct{}{}
+	for _, c := range cases {
+		caseName := c.Name
+		if caseName == "" {
+			caseName = formatVars(c.Vars)
+		}
+
+		for _, row := range rows {
+			vars := map[string]string{}
+			for name, value := range c.Vars {
+				vars[name] = value
+			}
+			for name, value := range row {
+				vars[name] = value
+			}
+
+			name := caseName
+			if len(row) > 0 {
+				if name != "" {
+					name += ", "
+				}
+				name += formatVars(row)
+			}
+
+			if _, ok := seen[name]; ok {
+				errors = append(errors, "Duplicate case: "+name)
+				continue
+			}
+			seen[name] = struct{}{}
+
+			test, undefined := raw.withVars(vars)
+			for _, v := range undefined {
+				warnings = append(warnings, "Case "+name+": Variable ${"+v+"} is not defined")
+			}
+
+			test.Matrix = nil
+			test.Cases = nil
+			test.TestDescription = strings.TrimSpace(test.TestDescription + " [" + name + "]")
+			test.caseName = name
+			test.vars = vars
+			expanded = append(expanded, test)
+		}
+	}
+
+	if len(errors) > 0 {
+		return nil, errors, warnings
+	}
+
+	return expanded, errors, warnings
+}
+
+// Every combination of the matrix values. The first
+// variable in names changes the slowest.
+func matrixCombinations(matrix map[string][]string, names []string) []map[string]string {
+	rows := []map[string]string{{}}
+
+	for _, name := range names {
+		var next []map[string]string
+		for _, row := range rows {
+			for _, value := range matrix[name] {
+				combined := map[string]string{name: value}
+				for k, v := range row {
+					combined[k] = v
+				}
+				next = append(next, combined)
+			}
+		}
+		rows = next
+	}
+
+	return rows
+}
+
+// Formats variables as "name=value, ..." sorted by name
+func formatVars(vars map[string]string) string {
+	var names []string
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		parts = append(parts, name+"="+vars[name])
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Replaces the ${var} placeholders in text. Placeholders
+// without a value are left as they are and returned so
+// that logs containing "${...}" can still be sent as is.
+func substituteVars(text string, vars map[string]string) (string, []string) {
+	var undefined []string
+
+	result := placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := placeholder[2 : len(placeholder)-1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		undefined = append(undefined, name)
+		return placeholder
+	})
+
+	return result, undefined
+}
+
+// Fills in the placeholders of a parameterized test in a
+// log read from a file
+func (lt *LogTest) FillVars(text string) string {
+	result, _ := substituteVars(text, lt.vars)
+	return result
+}
+
+// A copy of the test with the placeholders of every
+// expectation, log path and inline log replaced. The
+// contents of log files are replaced when they are sent.
+func (raw LogTest) withVars(vars map[string]string) (LogTest, []string) {
+	undefinedSet := map[string]struct{}{}
+
+	str := func(text string) string {
+		result, undefined := substituteVars(text, vars)
+		for _, name := range undefined {
+			undefinedSet[name] = struct{}{}
+		}
+		return result
+	}
+
+	strMap := func(m map[string]string) map[string]string {
+		if m == nil {
+			return nil
+		}
+		result := map[string]string{}
+		for key, value := range m {
+			result[str(key)] = str(value)
+		}
+		return result
+	}
+
+	var value func(v interface{}) interface{}
+	value = func(v interface{}) interface{} {
+		switch val := v.(type) {
+		case string:
+			return str(val)
+		case map[string]interface{}:
+			result := map[string]interface{}{}
+			for key, inner := range val {
+				result[str(key)] = value(inner)
+			}
+			return result
+		case []interface{}:
+			result := make([]interface{}, len(val))
+			for i, inner := range val {
+				result[i] = value(inner)
+			}
+			return result
+		}
+		return v
+	}
+
+	valueMap := func(m map[string]interface{}) map[string]interface{} {
+		if m == nil {
+			return nil
+		}
+		return value(m).(map[string]interface{})
+	}
+
+	test := raw
+	test.TestDescription = str(raw.TestDescription)
+	test.RuleID = str(raw.RuleID)
+	test.RuleLevel = str(raw.RuleLevel)
+	test.RuleDescription = str(raw.RuleDescription)
+	test.LogFilePath = str(raw.LogFilePath)
+	test.Decoder = strMap(raw.Decoder)
+	test.Predecoder = strMap(raw.Predecoder)
+	test.Data = valueMap(raw.Data)
+	test.Expect = valueMap(raw.Expect)
+
+	test.Sequence = nil
+	for _, step := range raw.Sequence {
+		step.Log = str(step.Log)
+		step.LogFilePath = str(step.LogFilePath)
+		step.RuleID = str(step.RuleID)
+		step.RuleLevel = str(step.RuleLevel)
+		step.RuleDescription = str(step.RuleDescription)
+		step.Decoder = strMap(step.Decoder)
+		step.Predecoder = strMap(step.Predecoder)
+		step.Data = valueMap(step.Data)
+		step.Expect = valueMap(step.Expect)
+		test.Sequence = append(test.Sequence, step)
+	}
+
+	var undefined []string
+	for name := range undefinedSet {
+		undefined = append(undefined, name)
+	}
+	sort.Strings(undefined)
+
+	return test, undefined
+}
@@ -0,0 +1,126 @@
+package testdef
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_substituteVars(t *testing.T) {
+	vars := map[string]string{"srcip": "10.0.0.1", "user": "bob"}
+
+	tests := []struct {
+		name          string
+		text          string
+		want          string
+		wantUndefined []string
+	}{
+		{name: "Valid single placeholder", text: "from ${srcip} port 22", want: "from 10.0.0.1 port 22"},
+		{name: "Valid several placeholders", text: "${user}@${srcip}", want: "bob@10.0.0.1"},
+		{name: "Valid no placeholders", text: "no placeholders", want: "no placeholders"},
+		{name: "Valid not a placeholder", text: "$srcip ${ srcip }", want: "$srcip ${ srcip }"},
+
+		{name: "Invalid undefined placeholder", text: "${dstip} ${srcip}", want: "${dstip} 10.0.0.1", wantUndefined: []string{"dstip"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, undefined := substituteVars(tt.text, vars)
+			if got != tt.want {
+				t.Errorf("substituteVars() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(undefined, tt.wantUndefined) {
+				t.Errorf("substituteVars() undefined = %v, want %v", undefined, tt.wantUndefined)
+			}
+		})
+	}
+}
+
+func Test_expandTestCases(t *testing.T) {
+	base := LogTest{
+		TestDescription: "SSH invalid user",
+		RuleID:          "5710",
+		LogFilePath:     "5710_${user}.txt",
+		Decoder:         map[string]string{"srcip": "${srcip}", "srcuser": "${user}"},
+	}
+
+	withCases := base
+	withCases.Cases = []testCase{
+		{Name: "IPv4", Vars: map[string]string{"srcip": "10.0.0.1"}},
+		{Vars: map[string]string{"srcip": "::1"}},
+	}
+	withCases.Matrix = map[string][]string{"user": {"bob", "alice"}}
+
+	expanded, errors, warnings := expandTestCases(withCases)
+	if len(errors) > 0 || len(warnings) > 0 {
+		t.Fatalf("expandTestCases() errors = %v, warnings = %v", errors, warnings)
+	}
+
+	var names []string
+	for _, test := range expanded {
+		names = append(names, test.caseName)
+	}
+	wantNames := []string{"IPv4, user=bob", "IPv4, user=alice", "srcip=::1, user=bob", "srcip=::1, user=alice"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("expandTestCases() cases = %v, want %v", names, wantNames)
+	}
+
+	first := expanded[0]
+	if first.TestDescription != "SSH invalid user [IPv4, user=bob]" {
+		t.Errorf("expandTestCases() description = %q", first.TestDescription)
+	}
+	if first.LogFilePath != "5710_bob.txt" {
+		t.Errorf("expandTestCases() log path = %q", first.LogFilePath)
+	}
+	wantDecoder := map[string]string{"srcip": "10.0.0.1", "srcuser": "bob"}
+	if !reflect.DeepEqual(first.Decoder, wantDecoder) {
+		t.Errorf("expandTestCases() decoder = %v, want %v", first.Decoder, wantDecoder)
+	}
+	if first.Matrix != nil || first.Cases != nil {
+		t.Errorf("expandTestCases() left Matrix or Cases set")
+	}
+	if base.Decoder["srcip"] != "${srcip}" {
+		t.Errorf("expandTestCases() changed the original test")
+	}
+
+	// Tests without cases are left as they are
+	plain, errors, _ := expandTestCases(base)
+	if len(errors) > 0 || len(plain) != 1 || plain[0].caseName != "" {
+		t.Errorf("expandTestCases() plain = %v, errors = %v", plain, errors)
+	}
+}
+
+func Test_expandTestCasesInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		matrix   map[string][]string
+		cases    []testCase
+		wantErrs int
+		wantWarn int
+	}{
+		{name: "Invalid matrix variable name", matrix: map[string][]string{"src-ip": {"a"}}, wantErrs: 1},
+		{name: "Invalid empty matrix variable", matrix: map[string][]string{"srcip": {}}, wantErrs: 1},
+		{name: "Invalid case without variables", cases: []testCase{{Name: "empty"}}, wantErrs: 1},
+		{name: "Invalid variable in cases and matrix", matrix: map[string][]string{"srcip": {"a"}}, cases: []testCase{{Vars: map[string]string{"srcip": "b"}}}, wantErrs: 1},
+		{name: "Invalid duplicate case", cases: []testCase{{Name: "a", Vars: map[string]string{"srcip": "1"}}, {Name: "a", Vars: map[string]string{"srcip": "2"}}}, wantErrs: 1},
+		{name: "Invalid too many cases", matrix: map[string][]string{"a": make([]string, 100), "b": make([]string, 11)}, wantErrs: 1},
+
+		{name: "Valid undefined placeholder", cases: []testCase{{Vars: map[string]string{"srcip": "1"}}}, wantWarn: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			raw := LogTest{RuleID: "${rule}", Matrix: tt.matrix, Cases: tt.cases}
+			if tt.wantWarn == 0 {
+				raw.RuleID = "5710"
+			}
+
+			_, errors, warnings := expandTestCases(raw)
+			if len(errors) != tt.wantErrs {
+				t.Errorf("expandTestCases() errors = %v, want %d", errors, tt.wantErrs)
+			}
+			if len(warnings) != tt.wantWarn {
+				t.Errorf("expandTestCases() warnings = %v, want %d", warnings, tt.wantWarn)
+			}
+		})
+	}
+}
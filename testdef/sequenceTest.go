@@ -0,0 +1,250 @@
+package testdef
+
+import (
+	"path/filepath"
+	"strconv"
+)
+
+// A single event of a sequence test. The log is either
+// inline (Log) or read from a file (LogFilePath). Every
+// expectation is optional, a step without any is only sent
+// to build up the state of the session.
+type SequenceStep struct {
+	Log         string `json:"Log"`
+	LogFilePath string `json:"LogFilePath"`
+
+	// Overrides the Format of the sequence test
+	Format string `json:"Format"`
+
+	// Send the event this many times. Each
+	// repetition is checked against the expectations.
+	Repeat int `json:"Repeat"`
+
+	RuleID          string                 `json:"RuleID"`
+	RuleLevel       string                 `json:"RuleLevel"`
+	RuleDescription string                 `json:"RuleDescription"`
+	Decoder         map[string]string      `json:"Decoder"`
+	Predecoder      map[string]string      `json:"Predecoder"`
+	Data            map[string]interface{} `json:"Data"`
+	Expect          map[string]interface{} `json:"Expect"`
+}
+
+// Builds a sequence test from the raw test decoded from a test
+// definition. The expectations are set on each step, so the top
+// level only holds the Version, Format and TestDescription.
+// Relative log file paths are resolved against defDir.
+func NewSequenceTest(raw LogTest, defDir string) (*LogTest, bool, []string, []string) {
+	lt := new(LogTest)
+
+	validTest := true
+	errors := []string{}
+	warnings := []string{}
+
+	// Version
+	valid, err, warn := IsValidVersion(raw.Version)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.Version = raw.Version
+
+	// Format used by steps that do not set their own
+	valid, err, warn = IsValidFormat(raw.Format)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.Format = raw.Format
+
+	// Test Description
+	valid, err, warn = IsValidTestDescription(raw.TestDescription)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.TestDescription = raw.TestDescription
+
+	// Timestamp of every event
+	valid, err, warn = IsValidTimestamp(raw.Timestamp)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.Timestamp = raw.Timestamp
+
+	// ID
+	valid, err, warn = IsValidTestID(raw.getExplicitID())
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.ID = raw.getExplicitID()
+
+	// Timeout of every event
+	valid, err, warn = IsValidTimeout(raw.Timeout)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.Timeout = raw.Timeout
+
+	// Markers
+	valid, err, warn = IsValidMarkers(raw)
+	errors = append(errors, err...)
+	warnings = append(warnings, warn...)
+	if !valid {
+		validTest = false
+	}
+	lt.Skip = raw.Skip
+	lt.ExpectFail = raw.ExpectFail
+	lt.Only = raw.Only
+
+	// Expectations belong to the steps
+	topLevel := []struct {
+		Field string
+		IsSet bool
+	}{
+		{"RuleID", raw.RuleID != ""},
+		{"RuleLevel", raw.RuleLevel != ""},
+		{"RuleDescription", raw.RuleDescription != ""},
+		{"LogFilePath", raw.LogFilePath != ""},
+		{"Decoder", len(raw.Decoder) > 0},
+		{"Predecoder", len(raw.Predecoder) > 0},
+		{"Data", len(raw.Data) > 0},
+		{"Expect", len(raw.Expect) > 0},
+	}
+	for _, field := range topLevel {
+		if field.IsSet {
+			errors = append(errors, field.Field+" must be set on the steps of a sequence test")
+			validTest = false
+		}
+	}
+
+	// Steps
+	hasExpectations := false
+	for i, step := range raw.Sequence {
+		if step.LogFilePath != "" {
+			step.LogFilePath = filepath.Join(defDir, step.LogFilePath)
+		}
+
+		valid, err, warn = IsValidSequenceStep(step)
+		for _, e := range err {
+			errors = append(errors, "Step "+strconv.Itoa(i+1)+": "+e)
+		}
+		for _, w := range warn {
+			warnings = append(warnings, "Step "+strconv.Itoa(i+1)+": "+w)
+		}
+		if !valid {
+			validTest = false
+		}
+
+		if step.hasExpectations() {
+			hasExpectations = true
+		}
+		lt.Sequence = append(lt.Sequence, step)
+	}
+
+	if !hasExpectations {
+		warnings = append(warnings, "Sequence has no expectations")
+	}
+
+	return lt, validTest, errors, warnings
+}
+
+// Checks a single step of a sequence test. Only the
+// expectations that are set are validated.
+func IsValidSequenceStep(step SequenceStep) (bool, []string, []string) {
+	validStep := true
+	errors := []string{}
+	warnings := []string{}
+
+	check := func(valid bool, err []string, warn []string) {
+		errors = append(errors, err...)
+		warnings = append(warnings, warn...)
+		if !valid {
+			validStep = false
+		}
+	}
+
+	// Log
+	switch {
+	case step.Log != "" && step.LogFilePath != "":
+		check(false, []string{"Step must set either Log or LogFilePath, not both"}, nil)
+	case step.Log != "":
+		// The lines are checked by IsValidLogContent
+	case step.LogFilePath != "":
+		check(IsValidLogFilePath(step.LogFilePath))
+	default:
+		check(false, []string{"Step must set either Log or LogFilePath"}, nil)
+	}
+
+	if step.Format != "" {
+		check(IsValidFormat(step.Format))
+	}
+
+	if step.Repeat < 0 {
+		check(false, []string{"Repeat cannot be negative"}, nil)
+	}
+
+	if step.RuleID != "" {
+		check(IsValidRuleID(step.RuleID))
+	}
+
+	if step.RuleLevel != "" {
+		check(IsValidRuleLevel(step.RuleLevel))
+	}
+
+	check(IsValidDecoder(step.Decoder))
+	check(IsValidPredecoder(step.Predecoder))
+	check(IsValidData(step.Data))
+	check(IsValidExpect(step.Expect))
+
+	return validStep, errors, warnings
+}
+
+func (step SequenceStep) hasExpectations() bool {
+	return step.RuleID != "" || step.RuleLevel != "" || step.RuleDescription != "" ||
+		len(step.Decoder) > 0 || len(step.Predecoder) > 0 || len(step.Data) > 0 || len(step.Expect) > 0
+}
+
+// How many times the event of the step is sent
+func (step SequenceStep) GetRepeat() int {
+	if step.Repeat < 1 {
+		return 1
+	}
+	return step.Repeat
+}
+
+// The step of every event that is sent in order, with
+// repeated steps listed once per repetition. The i-th
+// entry is the step of the i-th response in the result.
+func (lt *LogTest) GetSequenceEvents() []SequenceStep {
+	var events []SequenceStep
+	for _, step := range lt.Sequence {
+		for r := 0; r < step.GetRepeat(); r++ {
+			events = append(events, step)
+		}
+	}
+	return events
+}
+
+// The step of a sequence test that the nth event (from
+// zero) was sent for, counting repeated events
+func (lt *LogTest) GetStepOfEvent(n int) (SequenceStep, bool) {
+	if n < 0 {
+		return SequenceStep{}, false
+	}
+	for _, step := range lt.Sequence {
+		n -= step.GetRepeat()
+		if n < 0 {
+			return step, true
+		}
+	}
+	return SequenceStep{}, false
+}
@@ -0,0 +1,92 @@
+package testdef
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_NewSequenceTest(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "failed_login.log"), []byte("sshd[1602]: Invalid user bob\n"), 0600); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		raw          LogTest
+		wantValid    bool
+		wantErrors   int
+		wantWarnings int
+	}{
+		{
+			name: "Valid inline and file steps",
+			raw: LogTest{Version: "0.1", Format: "syslog", TestDescription: "Brute force", Sequence: []SequenceStep{
+				{Log: "sshd[1602]: Invalid user bob", Repeat: 7, RuleID: "5710"},
+				{LogFilePath: "failed_login.log", RuleID: "5712", RuleLevel: "10"},
+			}},
+			wantValid: true,
+		},
+		{
+			name: "Valid step without expectations",
+			raw: LogTest{Version: "0.1", Format: "syslog", TestDescription: "Warm up", Sequence: []SequenceStep{
+				{Log: "first"},
+				{Log: "second", Format: "json", RuleID: "100010"},
+			}},
+			wantValid: true,
+		},
+		{
+			name: "Valid sequence without any expectations",
+			raw: LogTest{Version: "0.1", Format: "syslog", TestDescription: "Nothing", Sequence: []SequenceStep{
+				{Log: "first"},
+			}},
+			wantValid:    true,
+			wantWarnings: 1,
+		},
+
+		{
+			name: "Invalid top level expectations",
+			raw: LogTest{Version: "0.1", Format: "syslog", TestDescription: "Top level", RuleID: "5712", LogFilePath: "failed_login.log", Sequence: []SequenceStep{
+				{Log: "first", RuleID: "5710"},
+			}},
+			wantValid:  false,
+			wantErrors: 2,
+		},
+		{
+			name: "Invalid step log",
+			raw: LogTest{Version: "0.1", Format: "syslog", TestDescription: "Logs", Sequence: []SequenceStep{
+				{RuleID: "5710"},
+				{Log: "first", LogFilePath: "failed_login.log"},
+				{LogFilePath: "missing.log"},
+			}},
+			wantValid:  false,
+			wantErrors: 3,
+		},
+		{
+			name: "Invalid step expectations",
+			raw: LogTest{Version: "0.1", Format: "syslog", TestDescription: "Expectations", Sequence: []SequenceStep{
+				{Log: "first", RuleID: "abc", RuleLevel: "17", Format: "bogus", Repeat: -1},
+			}},
+			wantValid:  false,
+			wantErrors: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			lt, valid, errors, warnings := NewSequenceTest(tt.raw, dir)
+			if valid != tt.wantValid {
+				t.Errorf("NewSequenceTest() valid = %v, want %v (errors: %v)", valid, tt.wantValid, errors)
+			}
+			if len(errors) != tt.wantErrors {
+				t.Errorf("NewSequenceTest() errors = %v, want %d", errors, tt.wantErrors)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("NewSequenceTest() warnings = %v, want %d", warnings, tt.wantWarnings)
+			}
+			if !lt.IsSequence() {
+				t.Errorf("isSequence() = false, want true")
+			}
+		})
+	}
+}
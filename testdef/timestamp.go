@@ -0,0 +1,156 @@
+package testdef
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Values of the Timestamp option besides a fixed time
+const (
+	timestampNow = "now"
+	timestampOff = "off"
+)
+
+// Layouts accepted for a fixed Timestamp. Times without a
+// zone are taken as local time.
+var fixedTimestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// A timestamp format that can be found at the start of a log.
+// Formats without a layout are formatted by formatISO8601.
+type timestampFormat struct {
+	name    string
+	pattern *regexp.Regexp
+	layout  string
+}
+
+// Checked in order, the first match wins
+var timestampFormats = []timestampFormat{
+	// Mar  5 13:49:34
+	{name: "syslog", pattern: regexp.MustCompile(`^[A-Z][a-z]{2} [ 0-3][0-9] [0-9]{2}:[0-9]{2}:[0-9]{2}`), layout: "Jan _2 15:04:05"},
+	// 2024-03-05T13:49:34.123+00:00
+	{name: "ISO8601", pattern: regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?(Z|[+-][0-9]{2}:?[0-9]{2})?`)},
+	// 3/5/2024 1:49:34 PM
+	{name: "Windows", pattern: regexp.MustCompile(`^[0-9]{1,2}/[0-9]{1,2}/[0-9]{4} [0-9]{1,2}:[0-9]{2}:[0-9]{2} [AP]M`), layout: "1/2/2006 3:04:05 PM"},
+	// 2024-03-05 13:49:34
+	{name: "Windows", pattern: regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2} [0-9]{2}:[0-9]{2}:[0-9]{2}`), layout: "2006-01-02 15:04:05"},
+}
+
+// Parses a fixed Timestamp
+func parseFixedTimestamp(value string) (time.Time, bool) {
+	for _, layout := range fixedTimestampLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Rewrites the timestamp at the start of the event to the
+// target time. A syslog priority before it is kept. Returns
+// the new event along with the old and new timestamps, or
+// false if no timestamp was found.
+func rewriteTimestamp(event string, target time.Time) (string, string, string, bool) {
+	priority := syslogPriorityPattern.FindString(event)
+	log := event[len(priority):]
+
+	for _, format := range timestampFormats {
+		old := format.pattern.FindString(log)
+		if old == "" {
+			continue
+		}
+
+		var rewritten string
+		switch {
+		case format.layout == "":
+			rewritten = formatISO8601(old, target)
+		case format.name == "syslog" && old[4] == '0':
+			// Zero padded day
+			rewritten = target.Format("Jan 02 15:04:05")
+		default:
+			rewritten = target.Format(format.layout)
+		}
+
+		return priority + rewritten + log[len(old):], old, rewritten, true
+	}
+
+	return event, "", "", false
+}
+
+// Formats the target like the original ISO8601 timestamp,
+// keeping its fractional digits and its time zone
+func formatISO8601(old string, target time.Time) string {
+	layout := "2006-01-02T15:04:05"
+
+	rest := old[len(layout):]
+	if strings.HasPrefix(rest, ".") {
+		digits := 1
+		for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+			digits++
+		}
+		layout += "." + strings.Repeat("0", digits-1)
+		rest = rest[digits:]
+	}
+
+	switch {
+	case rest == "Z":
+		layout += "Z07:00"
+		target = target.UTC()
+	case strings.Contains(rest, ":"):
+		layout += "-07:00"
+	case rest != "":
+		layout += "-0700"
+	}
+
+	// Keep the offset of the original timestamp
+	if rest != "" && rest != "Z" {
+		if original, err := time.Parse(layout, old); err == nil {
+			target = target.In(original.Location())
+		}
+	}
+
+	return target.Format(layout)
+}
+
+// Applies the Timestamp option of a test to an event. A
+// Predecoder timestamp expectation that matches the old
+// timestamp is changed to the new one. The predecoder map
+// is copied before it is changed.
+func ApplyTimestamp(setting string, event string, predecoder map[string]string) (string, map[string]string, []string) {
+	warnings := []string{}
+
+	if setting == "" || setting == timestampOff {
+		return event, predecoder, warnings
+	}
+
+	target := time.Now()
+	if setting != timestampNow {
+		fixed, ok := parseFixedTimestamp(setting)
+		if !ok {
+			// Checked when the test is loaded
+			return event, predecoder, warnings
+		}
+		target = fixed
+	}
+
+	rewritten, old, updated, ok := rewriteTimestamp(event, target)
+	if !ok {
+		warnings = append(warnings, "No timestamp found at the start of the log to rewrite")
+		return event, predecoder, warnings
+	}
+
+	if expected, ok := predecoder["timestamp"]; ok && expected == old {
+		adjusted := map[string]string{}
+		for key, value := range predecoder {
+			adjusted[key] = value
+		}
+		adjusted["timestamp"] = updated
+		predecoder = adjusted
+	}
+
+	return rewritten, predecoder, warnings
+}
@@ -0,0 +1,105 @@
+package testdef
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_rewriteTimestamp(t *testing.T) {
+	target := time.Date(2024, time.November, 9, 8, 7, 6, 123456789, time.FixedZone("", 2*60*60))
+
+	tests := []struct {
+		name    string
+		event   string
+		want    string
+		wantOld string
+		wantOk  bool
+	}{
+		{name: "Valid syslog", event: "Mar  5 13:49:34 host sshd[1602]: Invalid user", want: "Nov  9 08:07:06 host sshd[1602]: Invalid user", wantOld: "Mar  5 13:49:34", wantOk: true},
+		{name: "Valid syslog zero padded day", event: "Mar 05 13:49:34 host sshd", want: "Nov 09 08:07:06 host sshd", wantOld: "Mar 05 13:49:34", wantOk: true},
+		{name: "Valid ISO8601 UTC", event: "2023-03-05T13:49:34Z host app", want: "2024-11-09T06:07:06Z host app", wantOld: "2023-03-05T13:49:34Z", wantOk: true},
+		{name: "Valid ISO8601 offset and fraction", event: "2023-03-05T13:49:34.120-05:00 host", want: "2024-11-09T01:07:06.123-05:00 host", wantOld: "2023-03-05T13:49:34.120-05:00", wantOk: true},
+		{name: "Valid ISO8601 offset without colon", event: "2023-03-05T13:49:34+0100 host", want: "2024-11-09T07:07:06+0100 host", wantOld: "2023-03-05T13:49:34+0100", wantOk: true},
+		{name: "Valid ISO8601 no zone", event: "2023-03-05T13:49:34 host", want: "2024-11-09T08:07:06 host", wantOld: "2023-03-05T13:49:34", wantOk: true},
+		{name: "Valid Windows", event: "3/5/2023 1:49:34 PM Security", want: "11/9/2024 8:07:06 AM Security", wantOld: "3/5/2023 1:49:34 PM", wantOk: true},
+		{name: "Valid syslog priority", event: "<13>Mar  5 13:49:34 host sshd", want: "<13>Nov  9 08:07:06 host sshd", wantOld: "Mar  5 13:49:34", wantOk: true},
+		{name: "Valid RFC 5424 priority and version", event: "<165>1 2023-03-05T13:49:34Z host app", want: "<165>1 2024-11-09T06:07:06Z host app", wantOld: "2023-03-05T13:49:34Z", wantOk: true},
+		{name: "Valid Windows 24 hour", event: "2023-03-05 13:49:34 ALLOW TCP", want: "2024-11-09 08:07:06 ALLOW TCP", wantOld: "2023-03-05 13:49:34", wantOk: true},
+
+		{name: "Invalid no leading timestamp", event: "host sshd: Mar  5 13:49:34", want: "host sshd: Mar  5 13:49:34", wantOk: false},
+		{name: "Invalid JSON", event: `{"timestamp":"2023-03-05T13:49:34Z"}`, want: `{"timestamp":"2023-03-05T13:49:34Z"}`, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, old, _, ok := rewriteTimestamp(tt.event, target)
+			if got != tt.want || old != tt.wantOld || ok != tt.wantOk {
+				t.Errorf("rewriteTimestamp() = %q, %q, %v, want %q, %q, %v", got, old, ok, tt.want, tt.wantOld, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_ApplyTimestamp(t *testing.T) {
+	event := "Mar  5 13:49:34 host sshd[1602]: Invalid user"
+	predecoder := map[string]string{"hostname": "host", "timestamp": "Mar  5 13:49:34"}
+
+	got, gotPredecoder, warnings := ApplyTimestamp("2024-11-09T08:07:06", event, predecoder)
+	if got != "Nov  9 08:07:06 host sshd[1602]: Invalid user" {
+		t.Errorf("ApplyTimestamp() event = %q", got)
+	}
+	wantPredecoder := map[string]string{"hostname": "host", "timestamp": "Nov  9 08:07:06"}
+	if !reflect.DeepEqual(gotPredecoder, wantPredecoder) {
+		t.Errorf("ApplyTimestamp() predecoder = %v, want %v", gotPredecoder, wantPredecoder)
+	}
+	if predecoder["timestamp"] != "Mar  5 13:49:34" {
+		t.Errorf("ApplyTimestamp() changed the original predecoder")
+	}
+	if len(warnings) > 0 {
+		t.Errorf("ApplyTimestamp() warnings = %v", warnings)
+	}
+
+	// A different expected timestamp is left alone
+	_, gotPredecoder, _ = ApplyTimestamp("now", event, map[string]string{"timestamp": "Jan  1 00:00:00"})
+	if gotPredecoder["timestamp"] != "Jan  1 00:00:00" {
+		t.Errorf("ApplyTimestamp() predecoder = %v", gotPredecoder)
+	}
+
+	for _, setting := range []string{"", "off"} {
+		got, _, _ := ApplyTimestamp(setting, event, predecoder)
+		if got != event {
+			t.Errorf("ApplyTimestamp(%q) = %q, want the event unchanged", setting, got)
+		}
+	}
+
+	_, _, warnings = ApplyTimestamp("now", "no timestamp here", nil)
+	if len(warnings) != 1 {
+		t.Errorf("ApplyTimestamp() warnings = %v, want 1", warnings)
+	}
+}
+
+func Test_IsValidTimestamp(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp string
+		want      bool
+	}{
+		{name: "Valid empty", timestamp: "", want: true},
+		{name: "Valid now", timestamp: "now", want: true},
+		{name: "Valid off", timestamp: "off", want: true},
+		{name: "Valid RFC3339", timestamp: "2024-03-05T13:49:34Z", want: true},
+		{name: "Valid local time", timestamp: "2024-03-05 13:49:34", want: true},
+
+		{name: "Invalid word", timestamp: "yesterday", want: false},
+		{name: "Invalid syslog time", timestamp: "Mar  5 13:49:34", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got, _, _ := IsValidTimestamp(tt.timestamp); got != tt.want {
+				t.Errorf("IsValidTimestamp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
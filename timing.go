@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/runner"
+)
+
+func printSlowestTests(con console, testDirs []runner.TestDir, n int) {
+	timings := runner.SlowestTests(testDirs, n)
+	if len(timings) == 0 {
+		return
+	}
+
+	var total time.Duration
+	numTests := 0
+	for _, dir := range testDirs {
+		for _, result := range dir.Results {
+			if !result.Skipped {
+				total += result.Duration
+				numTests++
+			}
+		}
+	}
+
+	con.boldWhite("Slowest Tests:")
+	con.boldWhite("==============\n")
+
+	for _, timing := range timings {
+		con.white(fmt.Sprintf("%8s  %s %s", runner.FormatDuration(timing.Duration), timing.ID, timing.Description))
+	}
+
+	con.printf("\n")
+	con.white(fmt.Sprintf("%d tests spent %s waiting for responses, %s on average", numTests, runner.FormatDuration(total), runner.FormatDuration(total/time.Duration(numTests))))
+	con.printf("\n")
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// The API issues tokens that expire after 15 minutes by
+// default. Renew them before that between watch runs.
+const watchTokenMaxAge = 10 * time.Minute
+
+// The size and modification time of a watched file
+type fileStamp struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// Keeps the results of the last run so that only the tests
+// affected by a change have to be sent to the backend again.
+type testWatcher struct {
+	backend wazuh.LogTestBackend
+	args    Arguments
+	console *consoleReporter
+
+	// Test ID -> result of the last time it ran
+	results map[string]runner.Result
+
+	// Every watched file and the log files outside of
+	// the tests directory that the tests reference
+	stamps   map[string]fileStamp
+	logFiles []string
+}
+
+// Polls the tests directory and reruns the tests whose
+// definition or log files changed. This only returns if the
+// tests directory cannot be read at all or ctx is cancelled.
+func runWatch(ctx context.Context, backend wazuh.LogTestBackend, args Arguments) int {
+	watcher := &testWatcher{backend: backend, args: args, console: newConsoleReporter(newConsole(args), args.Verbosity, false), results: map[string]runner.Result{}}
+	interval := time.Duration(args.WatchInterval) * time.Second
+
+	stamps, err := snapshotFiles(args.TestsDir, nil)
+	if err != nil {
+		watcher.console.red("Error watching tests: " + err.Error())
+		return 1
+	}
+	watcher.runCycle(ctx, stamps, nil)
+
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return 1
+		}
+
+		stamps, err := snapshotFiles(args.TestsDir, watcher.logFiles)
+		if err != nil {
+			watcher.console.red("Error watching tests: " + err.Error())
+			return 1
+		}
+
+		changed := changedFiles(watcher.stamps, stamps)
+		if len(changed) == 0 {
+			continue
+		}
+
+		watcher.runCycle(ctx, stamps, changed)
+	}
+}
+
+// Reloads the test tree, runs every test that is new or
+// references one of the changed files and redraws the view.
+// A nil changed list is the first run.
+func (watcher *testWatcher) runCycle(ctx context.Context, stamps map[string]fileStamp, changed []string) {
+	args := watcher.args
+	watcher.stamps = stamps
+
+	if !args.CliMode {
+		// Clear the screen and move to the top
+		watcher.console.printf("\033[H\033[2J")
+	} else if changed != nil {
+		watcher.console.printf("\n")
+	}
+	watcher.console.boldWhite("Watching " + args.TestsDir + " every " + strconv.Itoa(args.WatchInterval) + "s. Press Ctrl-C to stop.")
+	if len(changed) > 0 {
+		var names []string
+		for _, path := range changed {
+			if rel, err := filepath.Rel(args.TestsDir, path); err == nil {
+				path = rel
+			}
+			names = append(names, path)
+		}
+		watcher.console.white("Changed: " + strings.Join(names, ", "))
+	}
+	watcher.console.printf("\n")
+
+	testDirs, err := runner.CollectTestDirs(args.TestsDir, args.RunFilter, watcher.console)
+	if err != nil {
+		watcher.console.red("Error loading tests: " + err.Error())
+		return
+	}
+
+	changedSet := map[string]struct{}{}
+	for _, path := range changed {
+		changedSet[path] = struct{}{}
+	}
+
+	// Only schedule the affected tests, keeping their
+	// position so the results can be mapped back
+	toRun := make([]runner.TestDir, len(testDirs))
+	var logFiles []string
+	numToRun := 0
+	for i, dir := range testDirs {
+		toRun[i] = runner.TestDir{TestDir: testdef.TestDir{Path: dir.Path}}
+		for _, test := range dir.Tests {
+			logFiles = append(logFiles, test.GetLogFilePaths()...)
+			if watcher.isAffected(test, changedSet) {
+				toRun[i].Tests = append(toRun[i].Tests, test)
+				numToRun++
+			}
+		}
+	}
+	watcher.logFiles = logFiles
+
+	// Referenced log files outside of the tests directory
+	// were not part of the snapshot the first time round
+	for _, path := range logFiles {
+		if _, ok := watcher.stamps[path]; !ok {
+			if info, err := os.Stat(path); err == nil {
+				watcher.stamps[path] = fileStamp{ModTime: info.ModTime(), Size: info.Size()}
+			}
+		}
+	}
+
+	if ws, ok := watcher.backend.(*wazuh.WazuhServer); ok {
+		if err := ws.RefreshAuthToken(watchTokenMaxAge); err != nil {
+			watcher.console.red("Error renewing API token: " + err.Error())
+			return
+		}
+	}
+
+	runner.RunTestPool(ctx, watcher.backend, toRun, args.Threads, nil)
+	if ctx.Err() != nil {
+		return
+	}
+
+	// Merge the new results with the previous ones and drop
+	// the results of tests that no longer exist
+	results := map[string]runner.Result{}
+	for i := range toRun {
+		for j, test := range toRun[i].Tests {
+			watcher.results[test.ID] = toRun[i].Results[j]
+		}
+	}
+	for i := range testDirs {
+		testDirs[i].Results = make([]runner.Result, len(testDirs[i].Tests))
+		for j, test := range testDirs[i].Tests {
+			results[test.ID] = watcher.results[test.ID]
+			testDirs[i].Results[j] = results[test.ID]
+		}
+	}
+	watcher.results = results
+
+	printWatchResults(watcher.console.console, testDirs, args.Verbosity)
+
+	summary := runner.SummarizeResults(testDirs)
+	line := fmt.Sprintf("%s  Ran %d of %d tests  Failed: %d  Warned: %d", time.Now().Format("15:04:05"), numToRun, summary.Total, summary.Failed, summary.Warned)
+	if summary.Skipped > 0 || summary.ExpectedFailures > 0 {
+		line += fmt.Sprintf("  Skipped: %d  Expected failures: %d", summary.Skipped, summary.ExpectedFailures)
+	}
+	if summary.Failed > 0 {
+		watcher.console.red(line)
+	} else {
+		watcher.console.green(line)
+	}
+}
+
+// A test is rerun when it has not run before or when its
+// definition or one of its log files changed
+func (watcher *testWatcher) isAffected(test testdef.LogTest, changed map[string]struct{}) bool {
+	if _, ok := watcher.results[test.ID]; !ok {
+		return true
+	}
+
+	if _, ok := changed[test.DefPath]; ok {
+		return true
+	}
+
+	for _, path := range test.GetLogFilePaths() {
+		if _, ok := changed[path]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// One line per failed test followed by its errors. Warnings
+// are only shown with -vv to keep the view compact.
+func printWatchResults(con console, testDirs []runner.TestDir, verbosity int) {
+	for _, dir := range testDirs {
+		for i, test := range dir.Tests {
+			result := dir.Results[i]
+			if result.UnexpectedPass {
+				con.yellow("[UNEXPECTEDLY PASSED] " + test.ID + " " + test.GetDisplayName() + ": " + test.ExpectFail)
+			}
+			if !result.Passed && !result.ExpectedFail {
+				con.red("[FAILED] " + test.ID + " " + test.GetDisplayName())
+				for _, e := range result.Errors {
+					con.red("+ " + e)
+				}
+			}
+			if verbosity > 1 && len(result.Warnings) > 0 {
+				if result.Passed {
+					con.yellow("[WARNING] " + test.ID + " " + test.GetDisplayName())
+				}
+				for _, w := range result.Warnings {
+					con.yellow("+ " + w)
+				}
+			}
+		}
+	}
+	con.printf("\n")
+}
+
+// Stamps every file under root along with the extra files
+// given. Extra files that do not exist are left out so they
+// show up as changed once they are created.
+func snapshotFiles(root string, extra []string) (map[string]fileStamp, error) {
+	stamps := map[string]fileStamp{}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// Removed while walking
+			return nil
+		}
+		stamps[path] = fileStamp{ModTime: info.ModTime(), Size: info.Size()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range extra {
+		if _, ok := stamps[path]; ok {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			stamps[path] = fileStamp{ModTime: info.ModTime(), Size: info.Size()}
+		}
+	}
+
+	return stamps, nil
+}
+
+// The sorted paths that were added, removed or modified
+// between two snapshots
+func changedFiles(before map[string]fileStamp, after map[string]fileStamp) []string {
+	var changed []string
+
+	for path, stamp := range after {
+		if old, ok := before[path]; !ok || !old.ModTime.Equal(stamp.ModTime) || old.Size != stamp.Size {
+			changed = append(changed, path)
+		}
+	}
+
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alexchristy/WazuhTest/runner"
+	"github.com/alexchristy/WazuhTest/testdef"
+)
+
+func Test_changedFiles(t *testing.T) {
+	now := time.Now()
+	before := map[string]fileStamp{
+		"tests/test_ssh.json": {ModTime: now, Size: 100},
+		"tests/5710.txt":      {ModTime: now, Size: 80},
+		"tests/5712.txt":      {ModTime: now, Size: 80},
+		"tests/203.txt":       {ModTime: now, Size: 40},
+	}
+	after := map[string]fileStamp{
+		"tests/test_ssh.json": {ModTime: now, Size: 100},
+		"tests/5710.txt":      {ModTime: now.Add(time.Second), Size: 80},
+		"tests/5712.txt":      {ModTime: now, Size: 81},
+		"tests/5104.txt":      {ModTime: now, Size: 60},
+	}
+
+	want := []string{"tests/203.txt", "tests/5104.txt", "tests/5710.txt", "tests/5712.txt"}
+	if got := changedFiles(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("changedFiles() = %v, want %v", got, want)
+	}
+
+	if got := changedFiles(after, after); len(got) != 0 {
+		t.Errorf("changedFiles() = %v, want no changes", got)
+	}
+}
+
+func Test_testWatcher_isAffected(t *testing.T) {
+	ssh := testdef.LogTest{ID: "test_ssh.json#1", LogFilePath: "tests/5710.txt", DefPath: "tests/test_ssh.json", DefIndex: 0}
+	sequence := testdef.LogTest{ID: "test_seq.json#1", Sequence: []testdef.SequenceStep{{Log: "inline"}, {LogFilePath: "tests/5712.txt"}}, DefPath: "tests/test_seq.json", DefIndex: 0}
+	added := testdef.LogTest{ID: "test_ssh.json#2", LogFilePath: "tests/203.txt", DefPath: "tests/test_ssh.json", DefIndex: 1}
+
+	watcher := &testWatcher{results: map[string]runner.Result{
+		ssh.ID:      {Passed: true},
+		sequence.ID: {Passed: true},
+	}}
+
+	tests := []struct {
+		name    string
+		test    testdef.LogTest
+		changed []string
+		want    bool
+	}{
+		{name: "Valid changed definition", test: ssh, changed: []string{"tests/test_ssh.json"}, want: true},
+		{name: "Valid changed log file", test: ssh, changed: []string{"tests/5710.txt"}, want: true},
+		{name: "Valid changed sequence log file", test: sequence, changed: []string{"tests/5712.txt"}, want: true},
+		{name: "Valid new test", test: added, changed: []string{}, want: true},
+
+		{name: "Invalid unrelated change", test: ssh, changed: []string{"tests/5712.txt"}, want: false},
+		{name: "Invalid unrelated sequence change", test: sequence, changed: []string{"tests/test_ssh.json"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			changed := map[string]struct{}{}
+			for _, path := range tt.changed {
+				changed[path] = struct{}{}
+			}
+			if got := watcher.isAffected(tt.test, changed); got != tt.want {
+				t.Errorf("isAffected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
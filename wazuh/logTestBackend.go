@@ -0,0 +1,28 @@
+package wazuh
+
+import "context"
+
+// Something that can process a log the same way the
+// Wazuh logtest API does.
+type LogTestSender interface {
+	// Returns the logtest result along with any warnings
+	// about how the log was processed. Backends that wait
+	// on the manager give up once ctx is done.
+	SendLogTest(ctx context.Context, event string, logFormat string) (map[string]interface{}, []string, error)
+}
+
+// A logtest backend is the Wazuh server itself or the
+// local rule engine emulator. Logs sent to the backend
+// directly share a single session.
+type LogTestBackend interface {
+	LogTestSender
+
+	// Opens a session that does not share any state
+	// (e.g. frequency rule counters) with other tests
+	NewSession() LogTestSession
+}
+
+type LogTestSession interface {
+	LogTestSender
+	Close() error
+}
@@ -1,4 +1,4 @@
-package main
+package wazuh
 
 import (
 	"crypto/tls"
@@ -0,0 +1,184 @@
+package wazuh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Ask the manager to check its configuration, including
+// the ruleset, for errors
+func (ws *WazuhServer) ValidateConfiguration() error {
+	req, err := http.NewRequest("GET", ws.getApiUrl("manager/configuration/validation", nil), nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %s", err)
+	}
+
+	headers := map[string]interface{}{
+		"Authorization": fmt.Sprintf("Bearer %s", ws.getAuthJwt()),
+	}
+
+	result, err := ws.sendRequest(req, headers)
+	if err != nil {
+		return fmt.Errorf("error validating configuration: %s", err)
+	}
+
+	if err := getApiError(result); err != nil {
+		return fmt.Errorf("invalid configuration: %s", err)
+	}
+
+	// Response format:
+	// {
+	//   "data": {
+	//     "affected_items": [{"name": "node01", "status": "KO", "details": [...]}],
+	//     ...
+	//   },
+	//   "error": 0
+	// }
+	data, ok := result["data"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected response format: no data field")
+	}
+
+	items, ok := data["affected_items"].([]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected response format: no affected_items field")
+	}
+
+	for _, item := range items {
+		node, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if status, _ := node["status"].(string); status != "OK" {
+			return fmt.Errorf("invalid configuration: %v", node["details"])
+		}
+	}
+
+	return nil
+}
+
+// Restart every daemon on the manager. The restart happens
+// in the background so WaitForAnalysisd should be used to
+// know when the manager is back.
+func (ws *WazuhServer) RestartManager() error {
+	return ws.sendManagerAction("manager/restart")
+}
+
+// Reload the ruleset of analysisd without a full restart.
+// This requires a manager version that provides the
+// endpoint.
+func (ws *WazuhServer) ReloadAnalysisd() error {
+	return ws.sendManagerAction("manager/analysisd/reload")
+}
+
+func (ws *WazuhServer) sendManagerAction(endpoint string) error {
+	req, err := http.NewRequest("PUT", ws.getApiUrl(endpoint, nil), nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %s", err)
+	}
+
+	headers := map[string]interface{}{
+		"Authorization": fmt.Sprintf("Bearer %s", ws.getAuthJwt()),
+	}
+
+	result, err := ws.sendRequest(req, headers)
+	if err != nil {
+		return fmt.Errorf("error sending %s: %s", endpoint, err)
+	}
+
+	if err := getApiError(result); err != nil {
+		return fmt.Errorf("error sending %s: %s", endpoint, err)
+	}
+
+	return nil
+}
+
+// Get the state of every daemon on the manager
+// (e.g. "wazuh-analysisd": "running")
+func (ws *WazuhServer) getManagerStatus() (map[string]string, error) {
+	req, err := http.NewRequest("GET", ws.getApiUrl("manager/status", nil), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %s", err)
+	}
+
+	headers := map[string]interface{}{
+		"Authorization": fmt.Sprintf("Bearer %s", ws.getAuthJwt()),
+	}
+
+	result, err := ws.sendRequest(req, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := result["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected response format: no data field")
+	}
+
+	items, ok := data["affected_items"].([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("unexpected response format: no affected_items field")
+	}
+
+	daemons, ok := items[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected response format: affected item is not an object")
+	}
+
+	status := map[string]string{}
+	for daemon, state := range daemons {
+		status[daemon] = fmt.Sprintf("%v", state)
+	}
+
+	return status, nil
+}
+
+// Poll the manager until analysisd is running again, the
+// timeout is reached or ctx is cancelled. Errors while the
+// API itself restarts are expected and ignored until the
+// timeout.
+func (ws *WazuhServer) WaitForAnalysisd(ctx context.Context, timeout time.Duration) error {
+	const pollInterval = 2 * time.Second
+
+	// Give the manager a moment to begin restarting so
+	// we do not see the daemons from before the restart
+	if err := sleepContext(ctx, pollInterval); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	var lastErr error
+	for time.Now().Before(deadline) {
+		status, err := ws.getManagerStatus()
+		if err == nil {
+			if strings.EqualFold(status["wazuh-analysisd"], "running") {
+				return nil
+			}
+			lastErr = fmt.Errorf("wazuh-analysisd is %s", status["wazuh-analysisd"])
+		} else {
+			lastErr = err
+		}
+
+		if err := sleepContext(ctx, pollInterval); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("manager did not come back after %s: %s", timeout, lastErr)
+}
+
+func sleepContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
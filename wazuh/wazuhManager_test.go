@@ -0,0 +1,55 @@
+package wazuh
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_ValidateConfiguration(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  string
+		wantErr string
+	}{
+		{name: "Valid every node OK", result: `{"data": {"affected_items": [{"name": "node01", "status": "OK"}, {"name": "node02", "status": "OK"}]}, "error": 0}`},
+
+		{name: "Invalid node KO", result: `{"data": {"affected_items": [{"name": "node01", "status": "OK"}, {"name": "node02", "status": "KO", "details": ["Invalid element in the rules"]}]}, "error": 0}`, wantErr: "invalid configuration: [Invalid element in the rules]"},
+		{name: "Invalid failed item", result: `{"data": {"failed_items": [{"error": {"code": 1908, "message": "Error validating configuration"}}]}, "error": 1}`, wantErr: "invalid configuration: Error validating configuration"},
+		{name: "Invalid missing items", result: `{"data": {}, "error": 0}`, wantErr: "unexpected response format: no affected_items field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ws := newFakeManager(t, tt.result)
+
+			err := ws.ValidateConfiguration()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateConfiguration() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ValidateConfiguration() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_WaitForAnalysisdCancelled(t *testing.T) {
+	ws := newFakeManager(t, `{"data": {"affected_items": [{"wazuh-analysisd": "stopped"}]}, "error": 0}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := ws.WaitForAnalysisd(ctx, time.Minute)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitForAnalysisd() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("WaitForAnalysisd() returned after %s, want right after cancel", elapsed)
+	}
+}
@@ -0,0 +1,47 @@
+package wazuh
+
+type Mitre struct {
+	ID        []string `json:"id,omitempty"`
+	Tactic    []string `json:"tactic,omitempty"`
+	Technique []string `json:"technique,omitempty"`
+}
+
+type Rule struct {
+	ID          string   `json:"id"`
+	Level       int      `json:"level"`
+	Description string   `json:"description"`
+	Groups      []string `json:"groups,omitempty"`
+	FiredTimes  int      `json:"firedtimes,omitempty"`
+	Mail        bool     `json:"mail,omitempty"`
+	Mitre       Mitre    `json:"mitre"`
+	PciDss      []string `json:"pci_dss,omitempty"`
+	Gdpr        []string `json:"gdpr,omitempty"`
+	Hipaa       []string `json:"hipaa,omitempty"`
+	Nist80053   []string `json:"nist_800_53,omitempty"`
+	Gpg13       []string `json:"gpg13,omitempty"`
+	Tsc         []string `json:"tsc,omitempty"`
+}
+
+type Output struct {
+	Rule       Rule                   `json:"rule"`
+	Predecoder map[string]string      `json:"predecoder,omitempty"`
+	Decoder    map[string]string      `json:"decoder,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	FullLog    string                 `json:"full_log,omitempty"`
+	Location   string                 `json:"location,omitempty"`
+	Timestamp  string                 `json:"timestamp,omitempty"`
+}
+
+type Data struct {
+	Output   Output   `json:"output"`
+	Messages []string `json:"messages,omitempty"`
+	Token    string   `json:"token,omitempty"`
+}
+
+type Response struct {
+	Data Data `json:"data"`
+
+	// The complete response as returned by the
+	// Wazuh server for generic Expect assertions
+	Raw map[string]interface{} `json:"-"`
+}
@@ -0,0 +1,249 @@
+package wazuh
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// A rule from the manager's loaded ruleset as returned
+// by the `GET /rules` endpoint
+type ManagerRule struct {
+	ID              int      `json:"id"`
+	Level           int      `json:"level"`
+	Description     string   `json:"description"`
+	Filename        string   `json:"filename"`
+	RelativeDirname string   `json:"relative_dirname"`
+	Status          string   `json:"status"`
+	Groups          []string `json:"groups"`
+	Mitre           []string `json:"mitre"`
+	PciDss          []string `json:"pci_dss"`
+	Gdpr            []string `json:"gdpr"`
+	Hipaa           []string `json:"hipaa"`
+	Nist80053       []string `json:"nist_800_53"`
+	Gpg13           []string `json:"gpg13"`
+	Tsc             []string `json:"tsc"`
+}
+
+// Fetch the manager's rule catalog. Filters are passed
+// straight through to the API (e.g. `filename` or
+// `relative_dirname`).
+func (ws *WazuhServer) GetRules(filters url.Values) ([]ManagerRule, error) {
+	items, err := ws.getAllAffectedItems("rules", filters)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching rules: %s", err)
+	}
+
+	var rules []ManagerRule
+	if err := convertAffectedItems(items, &rules); err != nil {
+		return nil, fmt.Errorf("error parsing rules: %s", err)
+	}
+
+	return rules, nil
+}
+
+// Converts the generic affected items from the API
+// into a slice of typed structs
+func convertAffectedItems(items []map[string]interface{}, out interface{}) error {
+	jsonBytes, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(jsonBytes, out)
+}
+
+// A decoder from the manager's loaded ruleset as returned
+// by the `GET /decoders` endpoint. Sibling decoders share
+// a name and are returned as separate items.
+type ManagerDecoder struct {
+	Name            string                 `json:"name"`
+	Filename        string                 `json:"filename"`
+	RelativeDirname string                 `json:"relative_dirname"`
+	Status          string                 `json:"status"`
+	Position        int                    `json:"position"`
+	Details         map[string]interface{} `json:"details"`
+}
+
+// Fetch the manager's decoder catalog. Filters are passed
+// straight through to the API (e.g. `filename` or
+// `relative_dirname`).
+func (ws *WazuhServer) GetDecoders(filters url.Values) ([]ManagerDecoder, error) {
+	items, err := ws.getAllAffectedItems("decoders", filters)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching decoders: %s", err)
+	}
+
+	var decoders []ManagerDecoder
+	if err := convertAffectedItems(items, &decoders); err != nil {
+		return nil, fmt.Errorf("error parsing decoders: %s", err)
+	}
+
+	return decoders, nil
+}
+
+func (md ManagerDecoder) GetParent() string {
+	parent, _ := md.Details["parent"].(string)
+	return parent
+}
+
+// The fields extracted by the decoder from its `order`
+// definition (e.g. "srcuser, srcip, srcport")
+func (md ManagerDecoder) GetOrderFields() []string {
+	var fields []string
+
+	switch order := md.Details["order"].(type) {
+	case string:
+		for _, field := range strings.Split(order, ",") {
+			field = strings.TrimSpace(field)
+			if field != "" {
+				fields = append(fields, field)
+			}
+		}
+	case []interface{}:
+		for _, field := range order {
+			if name, ok := field.(string); ok && name != "" {
+				fields = append(fields, strings.TrimSpace(name))
+			}
+		}
+	}
+
+	return fields
+}
+
+// List the names of the files in a ruleset directory of the
+// manager. The endpoint is one of `rules/files`,
+// `decoders/files` or `lists/files`.
+func (ws *WazuhServer) ListRulesetFiles(endpoint string, relativeDirname string) ([]string, error) {
+	filters := url.Values{}
+	filters.Set("relative_dirname", relativeDirname)
+
+	items, err := ws.getAllAffectedItems(endpoint, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %s", endpoint, err)
+	}
+
+	var filenames []string
+	for _, item := range items {
+		filename, ok := item["filename"].(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected response format: no filename field")
+		}
+		filenames = append(filenames, filename)
+	}
+
+	return filenames, nil
+}
+
+// Download the raw contents of a ruleset file
+func (ws *WazuhServer) GetRulesetFile(endpoint string, filename string) ([]byte, error) {
+	query := url.Values{}
+	query.Set("raw", "true")
+
+	req, err := http.NewRequest("GET", ws.getApiUrl(endpoint+"/"+url.PathEscape(filename), query), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %s", err)
+	}
+
+	headers := map[string]interface{}{
+		"Authorization": fmt.Sprintf("Bearer %s", ws.getAuthJwt()),
+	}
+
+	content, err := ws.sendRawRequest(req, headers)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading %s: %s", filename, err)
+	}
+
+	return content, nil
+}
+
+// Upload a ruleset file replacing any existing file
+// with the same name
+func (ws *WazuhServer) PutRulesetFile(endpoint string, filename string, content []byte) error {
+	query := url.Values{}
+	query.Set("overwrite", "true")
+
+	req, err := http.NewRequest("PUT", ws.getApiUrl(endpoint+"/"+url.PathEscape(filename), query), bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("error creating request: %s", err)
+	}
+
+	headers := map[string]interface{}{
+		"Content-Type":  "application/octet-stream",
+		"Authorization": fmt.Sprintf("Bearer %s", ws.getAuthJwt()),
+	}
+
+	result, err := ws.sendRequest(req, headers)
+	if err != nil {
+		return fmt.Errorf("error uploading %s: %s", filename, err)
+	}
+
+	if err := getApiError(result); err != nil {
+		return fmt.Errorf("error uploading %s: %s", filename, err)
+	}
+
+	return nil
+}
+
+func (ws *WazuhServer) DeleteRulesetFile(endpoint string, filename string) error {
+	req, err := http.NewRequest("DELETE", ws.getApiUrl(endpoint+"/"+url.PathEscape(filename), nil), nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %s", err)
+	}
+
+	headers := map[string]interface{}{
+		"Authorization": fmt.Sprintf("Bearer %s", ws.getAuthJwt()),
+	}
+
+	result, err := ws.sendRequest(req, headers)
+	if err != nil {
+		return fmt.Errorf("error deleting %s: %s", filename, err)
+	}
+
+	if err := getApiError(result); err != nil {
+		return fmt.Errorf("error deleting %s: %s", filename, err)
+	}
+
+	return nil
+}
+
+// The Wazuh API reports failures of individual items with a
+// 200 status code. This returns the first failure, if any.
+//
+// Response format:
+//
+//	{
+//	  "data": {
+//	    "failed_items": [{"error": {"code": 1113, "message": "..."}, "id": [...]}],
+//	    "total_failed_items": 1
+//	  },
+//	  "message": "Could not upload file",
+//	  "error": 1
+//	}
+func getApiError(result map[string]interface{}) error {
+	if result["error"] == float64(0) || result["error"] == nil {
+		return nil
+	}
+
+	if data, ok := result["data"].(map[string]interface{}); ok {
+		if failed, ok := data["failed_items"].([]interface{}); ok && len(failed) > 0 {
+			if item, ok := failed[0].(map[string]interface{}); ok {
+				if itemErr, ok := item["error"].(map[string]interface{}); ok {
+					if message, ok := itemErr["message"].(string); ok {
+						return errors.New(message)
+					}
+				}
+			}
+		}
+	}
+
+	if message, ok := result["message"].(string); ok {
+		return errors.New(message)
+	}
+
+	return errors.New("manager reported an error")
+}
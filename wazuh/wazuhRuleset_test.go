@@ -0,0 +1,81 @@
+package wazuh
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Serves the login endpoint and replies to every other
+// request with the given result and a 200
+func newFakeManager(t *testing.T, result string) *WazuhServer {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/security/user/authenticate" {
+			w.Write([]byte(`{"data": {"token": "token"}, "error": 0}`))
+			return
+		}
+		w.Write([]byte(result))
+	}))
+	t.Cleanup(server.Close)
+
+	ws, err := NewWazuhServerFromURL(strings.Replace(server.URL, "http://", "http://wazuh:wazuh@", 1), 5)
+	if err != nil {
+		t.Fatalf("Failed to connect to fake manager: %v", err)
+	}
+	return ws
+}
+
+func Test_getApiError(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  string
+		wantErr string
+	}{
+		{name: "Valid no error", result: `{"data": {"affected_items": []}, "error": 0}`},
+		{name: "Valid error field missing", result: `{"data": {"affected_items": []}}`},
+
+		{name: "Invalid failed item", result: `{"data": {"failed_items": [{"error": {"code": 1113, "message": "XML syntax error"}, "id": ["local_rules.xml"]}], "total_failed_items": 1}, "message": "Could not upload file", "error": 1}`, wantErr: "XML syntax error"},
+		{name: "Invalid message only", result: `{"message": "Permission denied", "error": 1}`, wantErr: "Permission denied"},
+		{name: "Invalid no message", result: `{"error": 2}`, wantErr: "manager reported an error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var result map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.result), &result); err != nil {
+				t.Fatalf("Failed to decode test JSON: %v", err)
+			}
+
+			err := getApiError(result)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("getApiError() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("getApiError() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Failed items come back with a 200 and must still fail
+// the request
+func Test_PutRulesetFileFailedItems(t *testing.T) {
+	ws := newFakeManager(t, `{"data": {"failed_items": [{"error": {"code": 1113, "message": "XML syntax error"}, "id": ["local_rules.xml"]}], "total_failed_items": 1}, "message": "Could not upload file", "error": 1}`)
+
+	err := ws.PutRulesetFile("rules/files", "local_rules.xml", []byte("<group>"))
+	want := "error uploading local_rules.xml: XML syntax error"
+	if err == nil || err.Error() != want {
+		t.Errorf("PutRulesetFile() error = %v, want %q", err, want)
+	}
+
+	err = ws.DeleteRulesetFile("rules/files", "local_rules.xml")
+	want = "error deleting local_rules.xml: XML syntax error"
+	if err == nil || err.Error() != want {
+		t.Errorf("DeleteRulesetFile() error = %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,115 @@
+package wazuh
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The logtest request is cancelled on the manager as soon as
+// ctx is done instead of running on in the background
+func Test_SendLogTestCancelled(t *testing.T) {
+	cancelled := make(chan struct{}, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/security/user/authenticate" {
+			w.Write([]byte(`{"data": {"token": "token"}, "error": 0}`))
+			return
+		}
+		// The server only notices the client going away
+		// once the body was read
+		io.ReadAll(r.Body)
+		select {
+		case <-r.Context().Done():
+			cancelled <- struct{}{}
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ws, err := NewWazuhServerFromURL(strings.Replace(server.URL, "http://", "http://wazuh:wazuh@", 1), 10)
+	if err != nil {
+		t.Fatalf("Failed to connect to fake manager: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		sender LogTestSender
+	}{
+		{name: "Invalid server request cancelled", sender: ws},
+		{name: "Invalid session request cancelled", sender: ws.NewSession()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			if _, _, err := tt.sender.SendLogTest(ctx, "event", "syslog"); err == nil {
+				t.Fatalf("SendLogTest() error = nil, want the request cancelled")
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("SendLogTest() returned after %s, want right after the timeout", elapsed)
+			}
+
+			select {
+			case <-cancelled:
+			case <-time.After(time.Second):
+				t.Errorf("SendLogTest() left the request running on the manager")
+			}
+		})
+	}
+}
+
+// A deadline on the request replaces the API timeout, so a
+// test can wait longer than -o
+func Test_SendLogTestDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/security/user/authenticate" {
+			w.Write([]byte(`{"data": {"token": "token"}, "error": 0}`))
+			return
+		}
+		time.Sleep(1500 * time.Millisecond)
+		w.Write([]byte(`{"data": {"output": {"rule": {"id": "5710"}}}, "error": 0}`))
+	}))
+	t.Cleanup(server.Close)
+
+	// The -o timeout is shorter than the response
+	ws, err := NewWazuhServerFromURL(strings.Replace(server.URL, "http://", "http://wazuh:wazuh@", 1), 1)
+	if err != nil {
+		t.Fatalf("Failed to connect to fake manager: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		deadline time.Duration
+		wantErr  string
+	}{
+		{name: "Valid deadline above API timeout", deadline: 5 * time.Second},
+
+		{name: "Invalid API timeout without deadline", wantErr: "connection to manager timed out after 1 seconds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			if tt.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+
+			_, _, err := ws.NewSession().SendLogTest(ctx, "event", "syslog")
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("SendLogTest() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("SendLogTest() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package wazuhtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/alexchristy/WazuhTest/wazuh"
+)
+
+// A recorded logtest response and the event it was the
+// response to
+type Fixture struct {
+	Event    string                 `json:"Event"`
+	Response map[string]interface{} `json:"Response"`
+}
+
+// Stands in for the manager by replying with recorded
+// logtest responses. Responses are looked up by the exact
+// event that was sent, so tests that rewrite their
+// timestamp to now cannot be replayed.
+type FixtureBackend struct {
+	// Event -> every response recorded for it in order
+	responses map[string][]map[string]interface{}
+}
+
+func NewFixtureBackend(fixtures []Fixture) *FixtureBackend {
+	backend := &FixtureBackend{responses: map[string][]map[string]interface{}{}}
+	for _, fixture := range fixtures {
+		backend.responses[fixture.Event] = append(backend.responses[fixture.Event], fixture.Response)
+	}
+	return backend
+}
+
+// Loads the fixtures from a JSON file holding a list of
+// Fixture objects
+func LoadFixtures(path string) (*FixtureBackend, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures []Fixture
+	if err := json.Unmarshal(content, &fixtures); err != nil {
+		return nil, fmt.Errorf("error parsing fixtures %s: %s", path, err)
+	}
+
+	return NewFixtureBackend(fixtures), nil
+}
+
+// Replies with the first response recorded for the event.
+// Logs sent directly to the backend share no state.
+func (backend *FixtureBackend) SendLogTest(ctx context.Context, event string, logFormat string) (map[string]interface{}, []string, error) {
+	return backend.reply(event, 0)
+}
+
+// Sessions reply with the responses of an event in the
+// order they were recorded, so the repeated events of a
+// sequence test can trigger a frequency rule. The last
+// response is repeated once they run out.
+func (backend *FixtureBackend) NewSession() wazuh.LogTestSession {
+	return &fixtureSession{backend: backend, sent: map[string]int{}}
+}
+
+func (backend *FixtureBackend) reply(event string, n int) (map[string]interface{}, []string, error) {
+	responses := backend.responses[event]
+	if len(responses) == 0 {
+		return nil, nil, fmt.Errorf("no fixture for event: %s", event)
+	}
+
+	if n >= len(responses) {
+		n = len(responses) - 1
+	}
+
+	return responses[n], nil, nil
+}
+
+type fixtureSession struct {
+	backend *FixtureBackend
+
+	// Event -> number of times it was sent
+	sent map[string]int
+}
+
+func (session *fixtureSession) SendLogTest(ctx context.Context, event string, logFormat string) (map[string]interface{}, []string, error) {
+	n := session.sent[event]
+	session.sent[event]++
+	return session.backend.reply(event, n)
+}
+
+func (session *fixtureSession) Close() error {
+	return nil
+}
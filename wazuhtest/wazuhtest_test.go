@@ -0,0 +1,84 @@
+package wazuhtest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/alexchristy/WazuhTest/runner"
+)
+
+func TestRunDir(t *testing.T) {
+	backend, err := LoadFixtures("testdata/fixtures.json")
+	if err != nil {
+		t.Fatalf("LoadFixtures() error = %v", err)
+	}
+
+	RunDir(t, "testdata/tests", Options{Backend: backend, Parallel: true})
+}
+
+// Runs the example tests against the manager in
+// WAZUH_MANAGER_URL
+func TestRunDirManager(t *testing.T) {
+	RunDir(t, "../wazuh-tests", Options{})
+}
+
+func TestFixtureBackend(t *testing.T) {
+	first := map[string]interface{}{"id": "first"}
+	second := map[string]interface{}{"id": "second"}
+	backend := NewFixtureBackend([]Fixture{
+		{Event: "event", Response: first},
+		{Event: "event", Response: second},
+	})
+	session := backend.NewSession()
+
+	tests := []struct {
+		name    string
+		send    func(ctx context.Context, event string, logFormat string) (map[string]interface{}, []string, error)
+		event   string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{name: "Valid backend first response", send: backend.SendLogTest, event: "event", want: first},
+		{name: "Valid backend repeats first response", send: backend.SendLogTest, event: "event", want: first},
+		{name: "Valid session first response", send: session.SendLogTest, event: "event", want: first},
+		{name: "Valid session second response", send: session.SendLogTest, event: "event", want: second},
+		{name: "Valid session repeats last response", send: session.SendLogTest, event: "event", want: second},
+
+		{name: "Invalid backend unknown event", send: backend.SendLogTest, event: "other", wantErr: true},
+		{name: "Invalid session unknown event", send: session.SendLogTest, event: "other", wantErr: true},
+	}
+	// Sessions keep state so these run in order
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := tt.send(context.Background(), tt.event, "syslog")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SendLogTest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SendLogTest() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_groupByFile(t *testing.T) {
+	testDirs, err := runner.CollectTestDirs("testdata/tests", nil, logReporter{t})
+	if err != nil {
+		t.Fatalf("CollectTestDirs() error = %v", err)
+	}
+
+	files := groupByFile("testdata/tests", testDirs)
+	if len(files) != 1 || files[0].name != "sshd/test_sshd.json" {
+		t.Fatalf("groupByFile() got %d files, want only sshd/test_sshd.json", len(files))
+	}
+
+	var names []string
+	for _, test := range files[0].tests {
+		names = append(names, subtestName(test))
+	}
+	want := []string{"SSH login to a non-existent user", "SSH brute force", "SSH login to a disabled user"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("subtestName() got = %v, want %v", names, want)
+	}
+}